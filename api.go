@@ -0,0 +1,255 @@
+package helpbot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/Raytar/helpbot/database"
+	helpbotv1 "github.com/Raytar/helpbot/helpbot/v1"
+	"github.com/Raytar/helpbot/helpbot/v1/helpbotv1connect"
+	"github.com/Raytar/helpbot/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --connect-go_out=. --connect-go_opt=paths=source_relative helpbot/v1/queue.proto
+
+// The queue API is the Connect-RPC service defined in helpbot/v1/queue.proto.
+// Clients can use the generated client, or send JSON messages, e.g.:
+//
+//	curl -H "Authorization: Bearer <api_token>" -H "Content-Type: application/json" \
+//		-d '{"guildId": "<discord server id>"}' http://localhost:8080/helpbot.v1.QueueService/ListQueue
+const (
+	apiShutdownTimeout   = 5 * time.Second
+	apiReadHeaderTimeout = 10 * time.Second
+)
+
+// queueService implements the queue API on behalf of the bot.
+type queueService struct {
+	*HelpBot
+}
+
+// newRequest returns the API representation of the help request.
+func newRequest(req *models.HelpRequest) *helpbotv1.QueueRequest {
+	r := &helpbotv1.QueueRequest{
+		Id:          uint64(req.ID),
+		StudentId:   req.StudentUserID,
+		AssistantId: req.AssistantUserID,
+		Type:        req.Type,
+		Assignment:  req.AssignmentName,
+		Group:       req.GroupName,
+		Status:      string(req.Status),
+		Description: req.Description,
+		CreatedAt:   timestamppb.New(req.CreatedAt),
+	}
+	if !req.AssignedAt.IsZero() {
+		r.AssignedAt = timestamppb.New(req.AssignedAt)
+	}
+	return r
+}
+
+func newRequests(requests []*models.HelpRequest) []*helpbotv1.QueueRequest {
+	result := make([]*helpbotv1.QueueRequest, len(requests))
+	for i, req := range requests {
+		result[i] = newRequest(req)
+	}
+	return result
+}
+
+// validToken returns true if the authorization header carries the API token as a bearer token.
+func validToken(authorization, token string) bool {
+	got, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// authInterceptor refuses requests that do not carry the API token.
+func authInterceptor(token string) connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if !validToken(req.Header().Get("Authorization"), token) {
+				return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid token"))
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// httpHandler returns the HTTP handler of the queue API and the dashboard.
+func (bot *HelpBot) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(helpbotv1connect.NewQueueServiceHandler(&queueService{bot}, connect.WithInterceptors(authInterceptor(bot.cfg.APIToken))))
+	bot.handleDashboard(mux)
+	bot.handleMetrics(mux)
+	return mux
+}
+
+// serveHTTP serves the queue API and the dashboard on the configured address until the context is canceled.
+func (bot *HelpBot) serveHTTP(ctx context.Context) {
+	srv := &http.Server{
+		Addr:              bot.cfg.APIAddress,
+		Handler:           bot.httpHandler(),
+		ReadHeaderTimeout: apiReadHeaderTimeout,
+		// Ends the dashboards' event streams when the bot shuts down
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	bot.log.Infof("Serving queue API on %s", bot.cfg.APIAddress)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		bot.log.Errorln("Queue API server failed:", err)
+	}
+}
+
+func (bot *queueService) ListQueue(_ context.Context, req *connect.Request[helpbotv1.ListQueueRequest]) (*connect.Response[helpbotv1.ListQueueResponse], error) {
+	guildID := req.Msg.GuildId
+	course, err := bot.db.GetCourse(&models.Course{GuildID: guildID})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no course is configured for guild %s", guildID))
+	}
+	waiting, err := bot.db.GetWaitingRequests(guildID, 0)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	sessions, err := bot.db.GetSessions(guildID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	onDuty, err := bot.db.GetOnDuty(guildID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&helpbotv1.ListQueueResponse{
+		QueueState: string(course.QueueState),
+		Waiting:    newRequests(waiting),
+		Sessions:   newRequests(sessions),
+		OnDuty:     int32(len(onDuty)),
+	}), nil
+}
+
+func (bot *queueService) GetPosition(_ context.Context, req *connect.Request[helpbotv1.GetPositionRequest]) (*connect.Response[helpbotv1.GetPositionResponse], error) {
+	guildID, studentID := req.Msg.GuildId, req.Msg.StudentId
+	active, err := bot.db.GetActiveRequest(guildID, studentID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	resp := &helpbotv1.GetPositionResponse{}
+	if active == nil {
+		return connect.NewResponse(resp), nil
+	}
+	resp.Request = newRequest(active)
+	if active.Status != models.StatusWaiting {
+		return connect.NewResponse(resp), nil
+	}
+	pos, err := bot.db.GetQueuePosition(guildID, studentID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	resp.Position = int32(pos)
+	if wait, err := bot.estimateWaitAt(guildID, pos); err == nil {
+		resp.EstimatedWaitSeconds = int64(wait.Seconds())
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (bot *queueService) Enqueue(ctx context.Context, req *connect.Request[helpbotv1.EnqueueRequest]) (*connect.Response[helpbotv1.EnqueueResponse], error) {
+	msg := req.Msg
+	if msg.Type != "help" && msg.Type != "approve" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unknown request type: %q", msg.Type))
+	}
+	student, err := bot.db.GetGuildStudent(msg.GuildId, msg.StudentId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if student == nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("the student is not registered in the guild"))
+	}
+
+	helpReq := &models.HelpRequest{
+		StudentUserID: msg.StudentId,
+		GuildID:       msg.GuildId,
+		Type:          msg.Type,
+		AssignmentID:  msg.AssignmentId,
+		Description:   msg.Description,
+	}
+	// Requests for group assignments are made on behalf of the group by default, as with the slash commands
+	asGroup := bot.getAssignment(msg.GuildId, msg.AssignmentId).GetIsGroupLab()
+	if msg.Group != nil {
+		asGroup = *msg.Group
+	}
+	ctx, cancel := context.WithTimeout(ctx, quickFeedTimeout)
+	defer cancel()
+	if refused := bot.prepareRequest(ctx, helpReq, asGroup); refused != "" {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New(refused))
+	}
+	if err := bot.db.WithActor(database.APIActor).CreateHelpRequest(helpReq); err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	bot.queueChanged(msg.GuildId)
+
+	resp := &helpbotv1.EnqueueResponse{Request: newRequest(helpReq)}
+	if helpReq.AssistantUserID != "" {
+		observeWaitTime(helpReq)
+		bot.openAPISession(helpReq)
+		return connect.NewResponse(resp), nil
+	}
+	pos, err := bot.db.GetQueuePosition(msg.GuildId, msg.StudentId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	resp.Position = int32(pos)
+	return connect.NewResponse(resp), nil
+}
+
+func (bot *queueService) Cancel(_ context.Context, req *connect.Request[helpbotv1.CancelRequest]) (*connect.Response[helpbotv1.CancelResponse], error) {
+	if err := bot.db.WithActor(database.APIActor).CancelHelpRequest(req.Msg.GuildId, req.Msg.StudentId); err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	bot.queueChanged(req.Msg.GuildId)
+	return connect.NewResponse(&helpbotv1.CancelResponse{}), nil
+}
+
+func (bot *queueService) Assign(_ context.Context, req *connect.Request[helpbotv1.AssignRequest]) (*connect.Response[helpbotv1.AssignResponse], error) {
+	msg := req.Msg
+	if !bot.isAssistant(msg.GuildId, msg.AssistantId) {
+		return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("%s is not a teaching assistant in the guild", msg.AssistantId))
+	}
+	var assigned, closed *models.HelpRequest
+	var err error
+	if msg.RequestId != 0 {
+		assigned, closed, err = bot.db.WithActor(database.APIActor).AssignRequest(msg.AssistantId, msg.GuildId, uint(msg.RequestId))
+	} else {
+		assigned, closed, err = bot.db.WithActor(database.APIActor).AssignNextRequest(msg.AssistantId, msg.GuildId, msg.Lane)
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	observeSessionTime(closed)
+	observeWaitTime(assigned)
+	bot.archiveSessionThread(closed)
+	bot.queueChanged(msg.GuildId)
+
+	resp := &helpbotv1.AssignResponse{}
+	if assigned != nil {
+		resp.Request = newRequest(assigned)
+		bot.openAPISession(assigned)
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// openAPISession starts the session of a request that was assigned through the queue API, like the slash commands do.
+// The assistant is sent a direct message about the request, since there is no interaction to reply to.
+func (bot *HelpBot) openAPISession(req *models.HelpRequest) {
+	// The thread is only created if the course has a session channel
+	thread, voiceMsg := bot.openSession("", req, fmt.Sprintf("<@%s>", req.AssistantUserID))
+	bot.notifyAssistant(req, fmt.Sprintf("<@%s>", req.StudentUserID), thread, voiceMsg)
+}
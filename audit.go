@@ -0,0 +1,183 @@
+package helpbot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Raytar/helpbot/database"
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAuditEntries is the maximum number of entries shown by /audit.
+const maxAuditEntries = 25
+
+// defaultAuditRetention is how long audit log entries are kept if the retention is not configured.
+const defaultAuditRetention = 90 * 24 * time.Hour
+
+// commandReply is the reply to a command or interaction that is being handled.
+type commandReply struct {
+	content string
+	err     error
+}
+
+// pendingReplies holds the replies to the commands and interactions that are being handled, such that they can be recorded
+// in the audit log. key is the interaction ID, and value is a *commandReply.
+var pendingReplies sync.Map
+
+// recordReply records the reply to the interaction if it is being handled.
+func recordReply(m *discordgo.InteractionCreate, content string, err error) {
+	if old, ok := pendingReplies.Load(m.ID); ok {
+		pendingReplies.CompareAndSwap(m.ID, old, &commandReply{content: content, err: err})
+	}
+}
+
+// recordCommand records the command and the bot's reply to it in the audit log.
+func (bot *HelpBot) recordCommand(m *discordgo.InteractionCreate) {
+	data := m.ApplicationCommandData()
+	bot.recordEntry(m, &models.AuditEntry{
+		Kind:    models.AuditCommand,
+		Action:  data.Name,
+		Details: formatOptions(data.Options),
+	})
+}
+
+// recordInteraction records the component or modal interaction and the bot's reply to it in the audit log.
+// The fields of submitted modals are not recorded, since they hold the students' descriptions of their problems.
+func (bot *HelpBot) recordInteraction(m *discordgo.InteractionCreate, customID string) {
+	prefix, args, _ := strings.Cut(customID, ":")
+	details := []string{args}
+	if m.Type == discordgo.InteractionMessageComponent {
+		if values := m.MessageComponentData().Values; len(values) > 0 {
+			details = append(details, "selected="+strings.Join(values, ","))
+		}
+	}
+	bot.recordEntry(m, &models.AuditEntry{
+		Kind:    models.AuditInteraction,
+		Action:  prefix,
+		Details: strings.TrimSpace(strings.Join(details, " ")),
+	})
+}
+
+// recordEntry adds the entry for the interaction to the audit log, together with the bot's reply to it.
+func (bot *HelpBot) recordEntry(m *discordgo.InteractionCreate, entry *models.AuditEntry) {
+	entry.GuildID = m.GuildID
+	entry.UserID = m.Member.User.ID
+	if v, ok := pendingReplies.LoadAndDelete(m.ID); ok {
+		r := v.(*commandReply)
+		entry.Outcome = r.content
+		if r.err != nil {
+			entry.Error = r.err.Error()
+		}
+	}
+	_ = bot.db.CreateAuditEntry(entry)
+}
+
+// actorDB returns the database with the interaction's user recorded as the actor of the changes made through it.
+func (bot *HelpBot) actorDB(m *discordgo.InteractionCreate) *database.Database {
+	return bot.db.WithActor(m.Member.User.ID)
+}
+
+// formatOptions returns the command's options as space-separated name=value pairs, preceded by the subcommand, if any.
+func formatOptions(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	parts := make([]string, 0, len(options))
+	for _, opt := range options {
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionSubCommand, discordgo.ApplicationCommandOptionSubCommandGroup:
+			parts = append(parts, strings.TrimSpace(opt.Name+" "+formatOptions(opt.Options)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s=%v", opt.Name, opt.Value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatAuditEntry returns a line describing the entry for /audit.
+func formatAuditEntry(entry *models.AuditEntry) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<t:%d:f> ", entry.CreatedAt.Unix())
+	switch entry.Kind {
+	case models.AuditCommand, models.AuditInteraction:
+		sb.WriteString("`")
+		if entry.Kind == models.AuditCommand {
+			sb.WriteString("/")
+		}
+		sb.WriteString(entry.Action)
+		if entry.Details != "" {
+			fmt.Fprintf(&sb, " %s", entry.Details)
+		}
+		sb.WriteString("`")
+		switch {
+		case entry.Error != "":
+			fmt.Fprintf(&sb, " failed: %s", truncate(entry.Error, 100))
+		case entry.Outcome != "":
+			fmt.Fprintf(&sb, " → %s", truncate(strings.ReplaceAll(entry.Outcome, "\n", " "), 100))
+		default:
+			sb.WriteString(" (no reply)")
+		}
+	case models.AuditChange:
+		fmt.Fprintf(&sb, "%s %s", entry.Action, entry.Target)
+		if entry.ActorID != "" {
+			fmt.Fprintf(&sb, " by %s", formatActor(entry.ActorID))
+		}
+		if entry.Details != "" {
+			fmt.Fprintf(&sb, ": `%s`", truncate(entry.Details, 150))
+		}
+	}
+	return sb.String()
+}
+
+// formatActor returns a mention of the user who made a change, or "the queue API".
+func formatActor(actorID string) string {
+	if actorID == database.APIActor {
+		return "the queue API"
+	}
+	return fmt.Sprintf("<@%s>", actorID)
+}
+
+func (bot *HelpBot) auditCommand(m *discordgo.InteractionCreate) {
+	opt := getOption(m, "user")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must mention a user.")
+		return
+	}
+	user := opt.UserValue(nil)
+	num := 10
+	if opt := getOption(m, "number"); opt != nil {
+		num = min(max(int(opt.IntValue()), 1), maxAuditEntries)
+	}
+
+	entries, err := bot.db.GetAuditEntries(m.GuildID, user.ID, num)
+	if err != nil {
+		replyMsg(bot.client, m, "Failed to get the audit log.")
+		return
+	}
+	if len(entries) == 0 {
+		replyMsg(bot.client, m, fmt.Sprintf("There are no audit log entries for %s.", user.Mention()))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The latest %d audit log entries for %s:\n\n", len(entries), user.Mention())
+	for _, entry := range entries {
+		line := formatAuditEntry(entry) + "\n"
+		if sb.Len()+len(line) > 2000 {
+			break
+		}
+		sb.WriteString(line)
+	}
+	replyMsg(bot.client, m, sb.String())
+}
+
+// pruneAuditLog deletes the audit log entries that are older than the configured retention.
+func (bot *HelpBot) pruneAuditLog(now time.Time) {
+	retention := defaultAuditRetention
+	if bot.cfg.AuditRetentionDays > 0 {
+		retention = time.Duration(bot.cfg.AuditRetentionDays) * 24 * time.Hour
+	}
+	if n, err := bot.db.DeleteAuditEntriesBefore(now.Add(-retention)); err == nil && n > 0 {
+		bot.log.Infof("Deleted %d audit log entries older than %s", n, retention)
+	}
+}
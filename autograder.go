@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"connectrpc.com/connect"
+	qfpb "github.com/quickfeed/quickfeed/qf"
 	"github.com/quickfeed/quickfeed/qf/qfconnect"
 )
 
@@ -19,12 +20,95 @@ func NewQuickFeed(authToken string) (*QuickFeed, error) {
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		},
 	}
-	qf := qfconnect.NewQuickFeedServiceClient(&client, "https://uis.itest.run", connect.WithInterceptors(tokenAuthClientInterceptor(authToken)))
+	qf := qfconnect.NewQuickFeedServiceClient(&client, "https://uis.itest.run", connect.WithInterceptors(tokenAuthClientInterceptor(authToken), metricsClientInterceptor()))
 	return &QuickFeed{
 		qf: qf,
 	}, nil
 }
 
+// GetAssignments returns the assignments of the given course.
+func (qf *QuickFeed) GetAssignments(ctx context.Context, courseID int64) ([]*qfpb.Assignment, error) {
+	resp, err := qf.qf.GetAssignments(ctx, connect.NewRequest(&qfpb.CourseRequest{CourseID: uint64(courseID)}))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg.GetAssignments(), nil
+}
+
+// GetEnrollment returns the enrollment in the given course of the user with the given GitHub login.
+// It returns nil if the user is not enrolled in the course.
+func (qf *QuickFeed) GetEnrollment(ctx context.Context, courseID int64, githubLogin string) (*qfpb.Enrollment, error) {
+	req := &qfpb.EnrollmentRequest{
+		FetchMode: &qfpb.EnrollmentRequest_CourseID{CourseID: uint64(courseID)},
+	}
+	enrollments, err := qf.qf.GetEnrollments(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range enrollments.Msg.GetEnrollments() {
+		if e.GetUser().GetLogin() == githubLogin {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetLatestSubmission returns the latest submission for the given assignment by the user, or by the group if groupID is non-zero.
+// It returns nil if no submission has been made for the assignment.
+func (qf *QuickFeed) GetLatestSubmission(ctx context.Context, courseID int64, userID, groupID, assignmentID uint64) (*qfpb.Submission, error) {
+	req := &qfpb.SubmissionRequest{
+		CourseID:  uint64(courseID),
+		FetchMode: &qfpb.SubmissionRequest_UserID{UserID: userID},
+	}
+	if groupID != 0 {
+		req.FetchMode = &qfpb.SubmissionRequest_GroupID{GroupID: groupID}
+	}
+	submissions, err := qf.qf.GetSubmissions(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	var latest *qfpb.Submission
+	for _, s := range submissions.Msg.GetSubmissions() {
+		if s.GetAssignmentID() == assignmentID && s.GetID() > latest.GetID() {
+			latest = s
+		}
+	}
+	return latest, nil
+}
+
+// UpdateSubmissionStatus sets the approval status of the submission for the given users.
+// The score and release status of the submission are left unchanged.
+func (qf *QuickFeed) UpdateSubmissionStatus(ctx context.Context, courseID int64, submission *qfpb.Submission, status qfpb.Submission_Status, userIDs ...uint64) error {
+	var grades []*qfpb.Grade
+	updated := make(map[uint64]bool)
+	for _, userID := range userIDs {
+		grades = append(grades, &qfpb.Grade{SubmissionID: submission.GetID(), UserID: userID, Status: status})
+		updated[userID] = true
+	}
+	for _, grade := range submission.GetGrades() {
+		if !updated[grade.GetUserID()] {
+			grades = append(grades, grade)
+		}
+	}
+	_, err := qf.qf.UpdateSubmission(ctx, connect.NewRequest(&qfpb.UpdateSubmissionRequest{
+		SubmissionID: submission.GetID(),
+		CourseID:     uint64(courseID),
+		Score:        submission.GetScore(),
+		Released:     submission.GetReleased(),
+		Grades:       grades,
+	}))
+	return err
+}
+
+// GetGroup returns the user's group in the given course.
+func (qf *QuickFeed) GetGroup(ctx context.Context, courseID int64, userID uint64) (*qfpb.Group, error) {
+	resp, err := qf.qf.GetGroupByUserAndCourse(ctx, connect.NewRequest(&qfpb.GroupRequest{CourseID: uint64(courseID), UserID: userID}))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
 // NewTokenAuthClientInterceptor returns a client interceptor that will add the given token in the Authorization header.
 func tokenAuthClientInterceptor(token string) connect.UnaryInterceptorFunc {
 	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
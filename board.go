@@ -0,0 +1,149 @@
+package helpbot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxBoardRequests is the maximum number of waiting requests listed on the queue board.
+const maxBoardRequests = 25
+
+// queueChanged is called whenever a request in the guild is created, assigned, cancelled or closed,
+// or the state of the guild's queue changes.
+func (bot *HelpBot) queueChanged(guildID string) {
+	go bot.updateBoard(guildID)
+	bot.dashboard.notify(guildID)
+	bot.updateQueueLength(guildID)
+}
+
+// updateBoard edits the guild's queue board message to show the current queue, if the guild has a board.
+func (bot *HelpBot) updateBoard(guildID string) {
+	// Serialize updates so that an older snapshot of the queue never overwrites a newer one
+	bot.boardMu.Lock()
+	defer bot.boardMu.Unlock()
+
+	course, err := bot.db.GetCourse(&models.Course{GuildID: guildID})
+	if err != nil || course.BoardMessageID == "" {
+		return
+	}
+	embed, err := bot.boardEmbed(course)
+	if err != nil {
+		bot.log.Errorln("Failed to create queue board:", err)
+		return
+	}
+	edit := discordgo.NewMessageEdit(course.BoardChannelID, course.BoardMessageID).SetEmbed(embed)
+	edit.Components = &[]discordgo.MessageComponent{boardButtons()}
+	if _, err := bot.client.ChannelMessageEditComplex(edit); err != nil {
+		bot.log.Errorln("Failed to update queue board:", err)
+	}
+}
+
+// boardEmbed returns an embed showing the course's queue and the teaching assistants on duty.
+func (bot *HelpBot) boardEmbed(course *models.Course) (*discordgo.MessageEmbed, error) {
+	requests, err := bot.db.GetWaitingRequests(course.GuildID, 0)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := bot.db.GetSessions(course.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := bot.db.GetWaitingAssistants(course.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	onDuty, err := bot.db.GetOnDuty(course.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	var queue strings.Builder
+	fmt.Fprintf(&queue, "The queue is **%s**.\n\n", course.QueueState)
+	if len(requests) == 0 {
+		queue.WriteString("There are no students waiting for help.")
+	}
+	for i, req := range requests {
+		if i == maxBoardRequests {
+			fmt.Fprintf(&queue, "... and %d more\n", len(requests)-maxBoardRequests)
+			break
+		}
+		fmt.Fprintf(&queue, "%d. <@%s> %s%s%s, waiting since <t:%d:R>\n", i+1, req.StudentUserID, req.Type, forAssignment(req), forGroup(req), req.CreatedAt.Unix())
+	}
+
+	var assistants strings.Builder
+	for _, session := range sessions {
+		fmt.Fprintf(&assistants, "<@%s> is helping <@%s> since <t:%d:R>\n", session.AssistantUserID, session.StudentUserID, session.AssignedAt.Unix())
+	}
+	busy := make(map[string]bool)
+	for _, session := range sessions {
+		busy[session.AssistantUserID] = true
+	}
+	for _, assistant := range waiting {
+		fmt.Fprintf(&assistants, "<@%s> is waiting for the next student\n", assistant.UserID)
+		busy[assistant.UserID] = true
+	}
+	for _, shift := range onDuty {
+		if !busy[shift.UserID] {
+			fmt.Fprintf(&assistants, "<@%s> is on duty since <t:%d:t>\n", shift.UserID, shift.StartedAt.Unix())
+		}
+	}
+	if assistants.Len() == 0 {
+		assistants.WriteString("No teaching assistants are on duty.")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Help queue for %s (%d waiting)", course.Name, len(requests)),
+		Color:       0x00ff00,
+		Description: queue.String(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: fmt.Sprintf("Teaching assistants (%d on duty)", len(onDuty)), Value: truncate(assistants.String(), 1024)},
+		},
+		Footer:    &discordgo.MessageEmbedFooter{Text: "Last updated"},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+func (bot *HelpBot) boardCommand(m *discordgo.InteractionCreate) {
+	opt := getOption(m, "channel")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must specify a channel for the queue board.")
+		return
+	}
+	channelID := opt.ChannelValue(nil).ID
+
+	course, err := bot.db.GetCourse(&models.Course{GuildID: m.GuildID})
+	if err != nil {
+		replyMsg(bot.client, m, "An unknown error occurred.")
+		return
+	}
+	embed, err := bot.boardEmbed(course)
+	if err != nil {
+		replyMsg(bot.client, m, "Failed to create the queue board.")
+		return
+	}
+	msg, err := bot.client.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: []discordgo.MessageComponent{boardButtons()},
+	})
+	if err != nil {
+		bot.log.Errorln("Failed to send queue board:", err)
+		replyMsg(bot.client, m, "Failed to send the queue board. Check that the bot can send messages in the channel.")
+		return
+	}
+	if err := bot.actorDB(m).SetBoard(m.GuildID, channelID, msg.ID); err != nil {
+		replyMsg(bot.client, m, "Failed to save the queue board.")
+		return
+	}
+
+	// The old board is no longer updated
+	if course.BoardMessageID != "" {
+		if err := bot.client.ChannelMessageDelete(course.BoardChannelID, course.BoardMessageID); err != nil {
+			bot.log.Errorln("Failed to delete old queue board:", err)
+		}
+	}
+	replyMsg(bot.client, m, fmt.Sprintf("The queue board was created in <#%s>.", channelID))
+}
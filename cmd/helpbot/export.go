@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Raytar/helpbot"
+	"github.com/Raytar/helpbot/database"
+)
+
+// runExport exports the help requests of a guild from the database, without connecting to Discord or QuickFeed.
+//
+//	helpbot -config config.json export -guild <guild id> -from 2024-01-01 -to 2024-06-30 -format csv -out requests.csv
+func runExport(config *helpbot.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	guildID := fs.String("guild", "", "ID of the Discord server to export the help requests of")
+	from := fs.String("from", "", "First day to export (YYYY-MM-DD)")
+	to := fs.String("to", "", "Last day to export (YYYY-MM-DD)")
+	format := fs.String("format", "csv", "Export format: csv or json")
+	out := fs.String("out", "", "Path to the output file (default: standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *guildID == "" {
+		return fmt.Errorf("the -guild flag is required")
+	}
+	if config.ExportKey == "" {
+		return fmt.Errorf("export_key must be set in the configuration file to pseudonymise the students")
+	}
+
+	loc := time.Local
+	if config.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(config.Timezone); err != nil {
+			return err
+		}
+	}
+	start, end, err := helpbot.ParseDateRange(*from, *to, loc)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.OpenDatabase(config.DBPath, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	requests, err := db.GetHelpRequests(*guildID, start, end)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := helpbot.ExportRequests(w, requests, *format, []byte(config.ExportKey)); err != nil {
+		return err
+	}
+	log.Infof("Exported %d help requests", len(requests))
+	return nil
+}
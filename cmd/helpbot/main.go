@@ -34,6 +34,13 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	if flag.Arg(0) == "export" {
+		if err := runExport(config, flag.Args()[1:]); err != nil {
+			log.Fatalf("Failed to export help requests: %v", err)
+		}
+		return
+	}
+
 	if config.GHToken == "" {
 		log.Fatalln("QUICKFEED_AUTH_TOKEN is not set")
 	}
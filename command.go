@@ -2,11 +2,12 @@ package helpbot
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
-	"connectrpc.com/connect"
 	"github.com/Raytar/helpbot/models"
 	"github.com/bwmarrin/discordgo"
 	qfpb "github.com/quickfeed/quickfeed/qf"
@@ -16,6 +17,20 @@ type command func(m *discordgo.InteractionCreate)
 
 type commandMap map[string]command
 
+// customIDMap maps the prefix of a custom ID, i.e., the part before the first ':', to the function
+// that handles the interaction. It routes submitted modals and message component interactions.
+type customIDMap map[string]command
+
+const (
+	modalGetHelp  = "gethelp"
+	modalDescribe = "describe"
+
+	componentCancel  = "cancel"
+	componentRefresh = "refresh"
+	componentNext    = "next"
+	componentClaim   = "claim"
+)
+
 func (bot *HelpBot) initCommands() {
 	bot.commands = commandMap{
 		// base commands
@@ -24,19 +39,50 @@ func (bot *HelpBot) initCommands() {
 		"configure": bot.configureCommand,
 
 		// student commands
-		"gethelp": bot.hasRole(func(m *discordgo.InteractionCreate) { bot.helpRequestCommand(m, "help") }, RoleStudent),
-		"approve": bot.hasRole(func(m *discordgo.InteractionCreate) { bot.helpRequestCommand(m, "approve") }, RoleStudent),
-		"cancel":  bot.hasRole(bot.cancelRequestCommand, RoleStudent),
-		"status":  bot.hasRole(bot.studentStatusCommand, RoleStudent),
+		"gethelp":  bot.hasRole(func(m *discordgo.InteractionCreate) { bot.helpRequestCommand(m, "help") }, RoleStudent),
+		"approve":  bot.hasRole(func(m *discordgo.InteractionCreate) { bot.helpRequestCommand(m, "approve") }, RoleStudent),
+		"cancel":   bot.hasRole(bot.cancelRequestCommand, RoleStudent),
+		"describe": bot.hasRole(bot.describeCommand, RoleStudent),
+		"status":   bot.hasRole(bot.studentStatusCommand, RoleStudent),
 
 		// assistant commands
 		"length":         bot.hasRole(bot.lengthCommand, RoleAssistant),
 		"list":           bot.hasRole(bot.listCommand, RoleAssistant),
 		"next":           bot.hasRole(bot.nextRequestCommand, RoleAssistant),
+		"take":           bot.hasRole(bot.takeCommand, RoleAssistant),
+		"lane":           bot.hasRole(bot.laneCommand, RoleAssistant),
+		"onduty":         bot.hasRole(bot.onDutyCommand, RoleAssistant),
+		"offduty":        bot.hasRole(bot.offDutyCommand, RoleAssistant),
+		"done":           bot.hasRole(bot.doneCommand, RoleAssistant),
+		"skip":           bot.hasRole(bot.skipCommand, RoleAssistant),
+		"grade":          bot.hasRole(bot.gradeCommand, RoleAssistant),
+		"scorelimit":     bot.hasRole(bot.scoreLimitCommand, RoleAssistant),
+		"strategy":       bot.hasRole(bot.strategyCommand, RoleAssistant),
+		"stats":          bot.hasRole(bot.statsCommand, RoleAssistant),
+		"export":         bot.hasRole(bot.exportCommand, RoleAssistant),
+		"audit":          bot.hasRole(bot.auditCommand, RoleAssistant),
+		"open":           bot.hasRole(bot.queueStateCommand(models.QueueOpen), RoleAssistant),
+		"close":          bot.hasRole(bot.queueStateCommand(models.QueueClosed), RoleAssistant),
+		"freeze":         bot.hasRole(bot.queueStateCommand(models.QueueFrozen), RoleAssistant),
+		"labhours":       bot.hasRole(bot.labHoursCommand, RoleAssistant),
+		"sessionchannel": bot.hasRole(bot.sessionChannelCommand, RoleAssistant),
+		"board":          bot.hasRole(bot.boardCommand, RoleAssistant),
 		"clear":          bot.hasRole(bot.clearCommand, RoleAssistant),
 		"unregister":     bot.hasRole(bot.unregisterCommand, RoleAssistant),
 		"cancel-waiting": bot.hasRole(bot.assistantCancelCommand, RoleAssistant),
 	}
+
+	bot.modals = customIDMap{
+		modalGetHelp:  bot.hasRole(bot.getHelpModalSubmit, RoleStudent),
+		modalDescribe: bot.hasRole(bot.describeModalSubmit, RoleStudent),
+	}
+
+	bot.components = customIDMap{
+		componentCancel:  bot.hasRole(bot.cancelButton, RoleStudent),
+		componentRefresh: bot.hasRole(bot.refreshButton, RoleStudent),
+		componentNext:    bot.hasRole(bot.nextRequestCommand, RoleAssistant),
+		componentClaim:   bot.hasRole(bot.claimSelect, RoleAssistant),
+	}
 }
 
 var baseHelp = createModal("Available commands",
@@ -47,11 +93,13 @@ register [course] [GitHub username]: Register your discord account as a student.
 
 var studentHelp = createModal("Student Commands",
 	``+"```"+`
-help:    Shows this help text
-gethelp: Request help from a teaching assistant
-approve: Get your lab approved by a teaching assistant
-cancel:  Cancels your help request and removes you from the queue
-status:  Show your position in the queue
+help:                 Shows this help text
+gethelp [assignment]: Request help from a teaching assistant, with a short description of the problem
+approve <assignment>: Get your lab approved by a teaching assistant
+                      Use the group option to make the request on behalf of your group
+describe:             Edit the description of your help request while you are waiting
+cancel:               Cancels your help request and removes you from the queue
+status:               Show your position in the queue and estimated wait time, or who is helping you
 `+"```"+`
 After requesting help, you can check the response message you got to see your position in the queue.
 You will receive a message when you are next in queue.
@@ -60,12 +108,35 @@ You will receive a message when you are next in queue.
 var assistant = createModal("Teaching Assistant Commands",
 	``+"```"+`
 help:               Shows this help text
+onduty:             Starts your shift. Students can see how many teaching assistants are on duty.
+offduty:            Ends your shift. You are signed off automatically when the lab hours end.
 length:             Returns the number of students waiting for help.
-list <num>:         Lists the next <num> students in the queue.
-next:               Removes and returns the first student from the queue.
-clear:              Clears the queue!
+list <num>:         Lists the next <num> students in the queue. Select a student in the list to take them.
+next <lane>:        Removes and returns the next student from the queue, optionally from the given lane.
+                    If the queue is empty, you will be assigned the next student who requests help.
+                    Your current session is closed as resolved.
+                    If you are in a voice channel, the student is moved into your voice channel.
+take <@|id>:        Takes the mentioned student's request, or the request with the given ID, from the queue.
+                    Your current session is closed as resolved.
+done <outcome>:     Closes your current session as resolved or no-show.
+skip:               Closes your current session as no-show, and gets the next student.
+grade <status>:     Sets the status of the submission in your current approval session on QuickFeed.
+scorelimit <bool>:  Sets whether approval requests require the assignment's score limit.
+stats <period>:     Shows the requests handled, average session length and wait, and clears per teaching assistant.
+export <format>:    Exports the help requests, optionally from and to the given days, with pseudonymised students.
+audit @mention <n>: Shows the <n> latest commands used by the mentioned user, changes they made, and changes to their requests.
+strategy <name>:    Sets which waiting teaching assistant is assigned a new request:
+                    the longest waiting, round-robin, or the one with the fewest sessions today.
+open:               Opens the queue for new requests.
+close:              Closes the queue for new requests until the next lab hours.
+freeze:             Closes the queue for new requests until it is opened with /open.
+labhours:           Adds, lists or removes the weekly lab hours during which the queue is open.
+sessionchannel <#>: Sets the channel in which private threads are created for help sessions.
+board <#>:          Creates a queue board in the channel that is updated whenever the queue changes.
+lane:               Sets, lists or removes the priority and weight of the lanes (request types) in the queue.
+clear <reason>:     Clears the queue! Each student in the queue is notified.
 unregister @mention Unregisters the mentioned user.
-cancel              Cancels your 'waiting' status.
+cancel-waiting      Cancels your 'waiting' status.
 `+"```", true)
 
 func (bot *HelpBot) helpCommand(m *discordgo.InteractionCreate) {
@@ -84,19 +155,160 @@ func (bot *HelpBot) helpCommand(m *discordgo.InteractionCreate) {
 }
 
 func (bot *HelpBot) helpRequestCommand(m *discordgo.InteractionCreate, requestType string) {
-	req := models.HelpRequest{
+	if msg := bot.queueClosedMsg(m.GuildID); msg != "" {
+		replyMsg(bot.client, m, msg)
+		return
+	}
+
+	var assignment *qfpb.Assignment
+	if opt := getOption(m, "assignment"); opt != nil {
+		// The value is the ID of a suggested assignment, or the name typed by the student
+		if assignment = bot.findAssignment(m.GuildID, opt.StringValue()); assignment == nil {
+			replyMsg(bot.client, m, fmt.Sprintf("Unknown assignment: %s. Pick one of the suggested assignments.", opt.StringValue()))
+			return
+		}
+	}
+	assignmentID := assignment.GetID()
+
+	// Requests for group assignments are made on behalf of the group by default
+	asGroup := assignment.GetIsGroupLab()
+	if opt := getOption(m, "group"); opt != nil {
+		asGroup = opt.BoolValue()
+	}
+
+	if requestType == "help" {
+		// The request is created when the student submits a description of the problem
+		customID := fmt.Sprintf("%s:%d:%t", modalGetHelp, assignmentID, asGroup)
+		replyModal(bot.client, m, descriptionModal(customID, "", ""))
+		return
+	}
+	bot.createHelpRequest(m, bot.newHelpRequest(m, requestType, assignmentID), asGroup)
+}
+
+// assignmentAutocomplete suggests the assignments whose names contain the text typed by the student.
+func (bot *HelpBot) assignmentAutocomplete(m *discordgo.InteractionCreate) {
+	var typed string
+	for _, opt := range m.ApplicationCommandData().Options {
+		if opt.Focused {
+			typed = strings.ToLower(opt.StringValue())
+		}
+	}
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	for _, assignment := range bot.loadAssignments(m.GuildID) {
+		if len(choices) == maxChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(assignment.GetName()), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  assignment.GetName(),
+				Value: fmt.Sprintf("%d", assignment.GetID()),
+			})
+		}
+	}
+	err := bot.client.InteractionRespond(m.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err != nil {
+		discordErrors.WithLabelValues(opRespond).Inc()
+		bot.log.Errorln("Failed to suggest assignments:", err)
+	}
+}
+
+// getHelpModalSubmit creates a help request with the description submitted by the student.
+func (bot *HelpBot) getHelpModalSubmit(m *discordgo.InteractionCreate) {
+	// The custom ID is gethelp:<assignment ID>:<group>
+	parts := strings.Split(m.ModalSubmitData().CustomID, ":")
+	if len(parts) != 3 {
+		replyMsg(bot.client, m, "Invalid request.")
+		return
+	}
+	assignmentID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		replyMsg(bot.client, m, "Invalid assignment.")
+		return
+	}
+	asGroup, _ := strconv.ParseBool(parts[2])
+
+	req := bot.newHelpRequest(m, "help", assignmentID)
+	values := modalValues(m)
+	req.Description = values["description"]
+	req.CodeLocation = values["location"]
+	bot.createHelpRequest(m, req, asGroup)
+}
+
+// newHelpRequest returns a new request of the given type by the user who sent the interaction.
+func (bot *HelpBot) newHelpRequest(m *discordgo.InteractionCreate, requestType string, assignmentID uint64) *models.HelpRequest {
+	req := &models.HelpRequest{
 		StudentUserID: m.Member.User.ID,
 		GuildID:       m.GuildID,
 		Type:          requestType,
 		Done:          false,
 	}
+	// The assignment's name is filled in by prepareRequest
+	req.AssignmentID = assignmentID
+	return req
+}
+
+// prepareRequest checks that the request may be added to the queue, and fills in the request's assignment
+// and group. The checks are shared by the slash commands and the queue API. It returns a message explaining
+// why the request is refused, or an empty string if the request may be created.
+func (bot *HelpBot) prepareRequest(ctx context.Context, req *models.HelpRequest, asGroup bool) string {
+	if msg := bot.queueClosedMsg(req.GuildID); msg != "" {
+		return msg
+	}
+
+	if req.AssignmentID != 0 {
+		assignment := bot.getAssignment(req.GuildID, req.AssignmentID)
+		if assignment == nil {
+			return "The assignment does not exist in this course."
+		}
+		req.AssignmentName = assignment.GetName()
+	} else if req.Type == "approve" {
+		return "You must choose the assignment you want approved."
+	}
+
+	if asGroup {
+		// Students who are not in a group, e.g., when working alone on a group assignment, make the request for themselves
+		if err := bot.setGroup(ctx, req); err != nil && !errors.Is(err, errNoGroup) {
+			return fmt.Sprintf("Failed to make the request on behalf of your group: %s", err)
+		}
+	}
+
+	if req.Type == "approve" {
+		return bot.checkScoreLimit(req)
+	}
+	return ""
+}
+
+// createHelpRequest adds the request to the queue, and replies with the student's position in the queue.
+func (bot *HelpBot) createHelpRequest(m *discordgo.InteractionCreate, req *models.HelpRequest, asGroup bool) {
+	// Finding the group and checking the score limit wait for QuickFeed
+	if (asGroup || req.Type == "approve") && !deferReply(bot.client, m) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quickFeedTimeout)
+	defer cancel()
+	if msg := bot.prepareRequest(ctx, req, asGroup); msg != "" {
+		replyMsg(bot.client, m, msg)
+		return
+	}
 
-	err := bot.db.CreateHelpRequest(&req)
+	err := bot.actorDB(m).CreateHelpRequest(req)
 	if err != nil {
 		bot.log.Errorln("helpRequest: failed to create new request:", err)
 		replyMsg(bot.client, m, fmt.Sprintf("An error occurred while creating your request: %s", err.Error()))
 		return
 	}
+	bot.queueChanged(m.GuildID)
+
+	if req.AssistantUserID != "" {
+		observeWaitTime(req)
+		bot.notifyAutoAssigned(m, req)
+		return
+	}
 
 	pos, err := bot.db.GetQueuePosition(m.GuildID, m.Member.User.ID)
 	if err != nil {
@@ -105,67 +317,242 @@ func (bot *HelpBot) helpRequestCommand(m *discordgo.InteractionCreate, requestTy
 		return
 	}
 
-	replyMsg(bot.client, m, fmt.Sprintf("A help request has been created, and you are at position %d in the queue.", pos))
+	msg := fmt.Sprintf("A help request has been created, and you are at position %d in the queue.%s", pos, bot.waitMsg(m.GuildID, pos))
+	if asGroup && req.GroupID == 0 {
+		msg += " You are not in a group on QuickFeed, so the request is only for you."
+	}
+	replyComponents(bot.client, m, msg, requestButtons())
 }
 
-func (bot *HelpBot) studentStatusCommand(m *discordgo.InteractionCreate) {
-	pos, err := bot.db.GetQueuePosition(m.GuildID, m.Member.User.ID)
+func (bot *HelpBot) describeCommand(m *discordgo.InteractionCreate) {
+	req, err := bot.db.GetActiveRequest(m.GuildID, m.Member.User.ID)
 	if err != nil {
-		bot.log.Errorln("studentStatus: failed to get position in queue:", err)
 		replyMsg(bot.client, m, "An error occurred.")
 		return
 	}
-	if pos <= 0 {
-		replyMsg(bot.client, m, "You are not in the queue.")
+	if req == nil || req.Status != models.StatusWaiting {
+		replyMsg(bot.client, m, "You do not have a help request waiting in the queue.")
 		return
 	}
-	replyMsg(bot.client, m, fmt.Sprintf("You are at position %d in the queue.", pos))
+	replyModal(bot.client, m, descriptionModal(modalDescribe, req.Description, req.CodeLocation))
+}
+
+// describeModalSubmit updates the description of the student's waiting request.
+func (bot *HelpBot) describeModalSubmit(m *discordgo.InteractionCreate) {
+	values := modalValues(m)
+	if err := bot.actorDB(m).UpdateDescription(m.GuildID, m.Member.User.ID, values["description"], values["location"]); err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to update your request: %s", err))
+		return
+	}
+	replyMsg(bot.client, m, "The description of your request was updated.")
+}
+
+// notifyAutoAssigned starts the session when a new request was assigned directly to a waiting assistant,
+// and notifies the student and the assistant.
+func (bot *HelpBot) notifyAutoAssigned(m *discordgo.InteractionCreate, req *models.HelpRequest) {
+	// Starting the session takes several requests to Discord
+	if !deferReply(bot.client, m) {
+		return
+	}
+	name := fmt.Sprintf("<@%s>", req.AssistantUserID)
+	if assistant, err := bot.client.GuildMember(m.GuildID, req.AssistantUserID); err == nil {
+		name = getMentionAndNick(assistant)
+	} else {
+		bot.log.Errorln("Failed to fetch assistant:", err)
+	}
+	thread, voiceMsg := bot.openSession(m.ChannelID, req, name)
+	replyMsg(bot.client, m, fmt.Sprintf("A help request has been created. You will now receive help from %s.%s", name, threadMsg(thread)))
+	bot.notifyAssistant(req, getMentionAndNick(m.Member), thread, voiceMsg)
+}
+
+func (bot *HelpBot) studentStatusCommand(m *discordgo.InteractionCreate) {
+	msg, _ := bot.statusMsg(m.GuildID, m.Member.User.ID)
+	replyMsg(bot.client, m, msg)
+}
+
+// statusMsg returns a message with the student's position in the queue and estimated wait time,
+// or who is helping the student. It also returns whether the student's request is waiting in the queue.
+func (bot *HelpBot) statusMsg(guildID, studentID string) (string, bool) {
+	req, err := bot.db.GetActiveRequest(guildID, studentID)
+	if err != nil {
+		bot.log.Errorln("studentStatus: failed to get active request:", err)
+		return "An error occurred.", false
+	}
+	if req != nil && req.Status == models.StatusInProgress {
+		assistant, err := bot.client.GuildMember(guildID, req.AssistantUserID)
+		if err != nil {
+			bot.log.Errorln("studentStatus: failed to fetch assistant:", err)
+			return "You are currently being helped by a teaching assistant.", false
+		}
+		return fmt.Sprintf("You are currently being helped by %s (since <t:%d:t>).", getMentionAndNick(assistant), req.AssignedAt.Unix()), false
+	}
+
+	pos, err := bot.db.GetQueuePosition(guildID, studentID)
+	if err != nil {
+		bot.log.Errorln("studentStatus: failed to get position in queue:", err)
+		return "An error occurred.", false
+	}
+	if pos <= 0 {
+		return "You are not in the queue.", false
+	}
+	return fmt.Sprintf("You are at position %d in the queue.%s%s", pos, bot.waitMsg(guildID, pos), bot.onDutyMsg(guildID)), true
 }
 
 func (bot *HelpBot) cancelRequestCommand(m *discordgo.InteractionCreate) {
-	if err := bot.db.CancelHelpRequest(m.GuildID, m.Member.User.ID); err != nil {
+	if err := bot.actorDB(m).CancelHelpRequest(m.GuildID, m.Member.User.ID); err != nil {
 		replyMsg(bot.client, m, fmt.Sprintf("No active request found: %s", err))
 	} else {
 		replyMsg(bot.client, m, "Your request was cancelled.")
+		bot.queueChanged(m.GuildID)
 	}
 }
 
 func (bot *HelpBot) nextRequestCommand(m *discordgo.InteractionCreate) {
-	request, err := bot.db.AssignNextRequest(m.Member.User.ID, m.GuildID)
+	var lane string
+	if opt := getOption(m, "lane"); opt != nil {
+		lane = opt.StringValue()
+	}
+	// The current session, if any, is closed by AssignNextRequest
+	request, closed, err := bot.actorDB(m).AssignNextRequest(m.Member.User.ID, m.GuildID, lane)
 	if err != nil {
 		bot.log.Errorf("Failed to assign next request: %v by user: %s in guild: %s", err, m.Member.User.ID, m.GuildID)
 		replyMsg(bot.client, m, fmt.Sprintf("Failed to assign next request: %s", err))
 		return
 	}
+	observeSessionTime(closed)
+	observeWaitTime(request)
+	bot.archiveSessionThread(closed)
+	bot.queueChanged(m.GuildID)
 
 	if request == nil || request.StudentUserID == "" {
-		replyMsg(bot.client, m, "No requests in queue.")
+		if lane != "" {
+			replyMsg(bot.client, m, fmt.Sprintf("No '%s' requests in queue. You will receive a message when the next student makes a '%s' request.", lane, lane))
+			return
+		}
+		replyMsg(bot.client, m, "No requests in queue. You will receive a message when the next student requests help.")
+		return
+	}
+	bot.startSession(m, request)
+}
+
+func (bot *HelpBot) takeCommand(m *discordgo.InteractionCreate) {
+	if opt := getOption(m, "id"); opt != nil {
+		bot.takeRequest(m, uint(opt.IntValue()))
+		return
+	}
+	opt := getOption(m, "student")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must specify a student or a request ID.")
 		return
 	}
-	student, err := bot.client.GuildMember(m.GuildID, request.StudentUserID)
+	student := opt.UserValue(nil)
+	req, err := bot.db.GetActiveRequest(m.GuildID, student.ID)
 	if err != nil {
-		bot.log.Errorln("Failed to fetch user:", err)
-		replyMsg(bot.client, m, "An unknown error occurred.")
+		replyMsg(bot.client, m, "An error occurred while fetching the student's request.")
 		return
 	}
+	if req == nil || req.Status != models.StatusWaiting {
+		replyMsg(bot.client, m, fmt.Sprintf("<@%s> does not have a request waiting in the queue.", student.ID))
+		return
+	}
+	bot.takeRequest(m, req.ID)
+}
 
-	if !replyMsg(bot.client, m, fmt.Sprintf("Next '%s' request is by %s.", request.Type, getMentionAndNick(student))) {
+// takeRequest assigns the waiting request with the given ID to the assistant, instead of the oldest waiting request.
+func (bot *HelpBot) takeRequest(m *discordgo.InteractionCreate, requestID uint) {
+	request, closed, err := bot.actorDB(m).AssignRequest(m.Member.User.ID, m.GuildID, requestID)
+	if err != nil {
+		bot.log.Errorf("Failed to assign request %d: %v by user: %s in guild: %s", requestID, err, m.Member.User.ID, m.GuildID)
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to take the request: %s", err))
 		return
 	}
-	sendMsg(bot.client, student.User, fmt.Sprintf("You will now receive help from %s", getMentionAndNick(m.Member)))
+	observeSessionTime(closed)
+	observeWaitTime(request)
+	bot.archiveSessionThread(closed)
+	bot.queueChanged(m.GuildID)
+	bot.startSession(m, request)
+}
+
+// startSession starts the session of the request that was assigned to the assistant,
+// and replies to the assistant with the request.
+func (bot *HelpBot) startSession(m *discordgo.InteractionCreate, request *models.HelpRequest) {
+	// Starting the session takes several requests to Discord, and to QuickFeed for approval requests
+	if !deferReply(bot.client, m) {
+		return
+	}
+
+	name := fmt.Sprintf("<@%s>", request.StudentUserID)
+	if student, err := bot.client.GuildMember(m.GuildID, request.StudentUserID); err == nil {
+		name = getMentionAndNick(student)
+	} else {
+		bot.log.Errorln("Failed to fetch user:", err)
+	}
+
+	var embeds []*discordgo.MessageEmbed
+	if request.Description != "" {
+		embeds = append(embeds, descriptionEmbed(request))
+	}
+	if request.Type == "approve" && request.AssignmentID != 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), quickFeedTimeout)
+		defer cancel()
+		if submission, userID, err := bot.getLatestSubmission(ctx, request); err != nil {
+			bot.log.Errorln("Failed to get submission from QuickFeed:", err)
+			embeds = append(embeds, &discordgo.MessageEmbed{
+				Title:       "Latest submission",
+				Description: fmt.Sprintf("Failed to get the submission from QuickFeed: %s", err),
+			})
+		} else {
+			embeds = append(embeds, submissionEmbed(request, submission, userID))
+		}
+	}
+
+	thread, voiceMsg := bot.openSession(m.ChannelID, request, getMentionAndNick(m.Member))
+	replyEmbed(bot.client, m, sessionMsg(request, name, thread, voiceMsg), embeds...)
+}
+
+func (bot *HelpBot) doneCommand(m *discordgo.InteractionCreate) {
+	status := models.StatusResolved
+	if opt := getOption(m, "outcome"); opt != nil {
+		status = models.RequestStatus(opt.StringValue())
+	}
+
+	request, err := bot.actorDB(m).CloseSession(m.Member.User.ID, m.GuildID, status)
+	if err != nil {
+		bot.log.Errorf("Failed to close session: %v by user: %s in guild: %s", err, m.Member.User.ID, m.GuildID)
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to close session: %s", err))
+		return
+	}
+	if request == nil {
+		replyMsg(bot.client, m, "You do not have a session in progress.")
+		return
+	}
+	observeSessionTime(request)
+	bot.archiveSessionThread(request)
+	bot.queueChanged(m.GuildID)
+
+	duration := request.DoneAt.Sub(request.AssignedAt).Round(time.Second)
+	replyMsg(bot.client, m, fmt.Sprintf("Your session with <@%s> was closed as '%s' after %s.", request.StudentUserID, status, duration))
+}
+
+// forAssignment returns a suffix naming the request's assignment, if any.
+func forAssignment(req *models.HelpRequest) string {
+	if req.AssignmentName == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for %s", req.AssignmentName)
 }
 
 func (bot *HelpBot) lengthCommand(m *discordgo.InteractionCreate) {
-	requests, err := bot.db.GetWaitingRequests(m.GuildID, 0)
+	total, lanes, err := bot.laneCounts(m.GuildID)
 	if err != nil {
 		replyMsg(bot.client, m, "An error occurred.")
 		return
 	}
 	var msg string
-	if len(requests) == 1 {
-		msg = "There is 1 student waiting for help."
+	if total == 1 {
+		msg = fmt.Sprintf("There is 1 student waiting for help (%s).", lanes)
 	} else {
-		msg = fmt.Sprintf("There are %d students waiting for help.", len(requests))
+		msg = fmt.Sprintf("There are %d students waiting for help (%s).", total, lanes)
 	}
 	replyMsg(bot.client, m, msg)
 }
@@ -193,7 +580,13 @@ func (bot *HelpBot) listCommand(m *discordgo.InteractionCreate) {
 		return
 	}
 
-	fmt.Fprintf(&sb, "Showing the next %d requests:\n\n", len(requests))
+	total, lanes, err := bot.laneCounts(m.GuildID)
+	if err != nil {
+		replyMsg(bot.client, m, "Failed to get list of requests.")
+		return
+	}
+	fmt.Fprintf(&sb, "Showing the next %d of %d requests (%s):\n\n", len(requests), total, lanes)
+	members := make(map[string]*discordgo.Member)
 	for i, req := range requests {
 		user, err := bot.client.GuildMember(m.GuildID, req.StudentUserID)
 		if err != nil {
@@ -201,31 +594,53 @@ func (bot *HelpBot) listCommand(m *discordgo.InteractionCreate) {
 			replyMsg(bot.client, m, "An error occurred while sending the message")
 			return
 		}
-		fmt.Fprintf(&sb, "%d. User: %s, Type: %s\n", i+1, getMentionAndNick(user), req.Type)
+		fmt.Fprintf(&sb, "%d. User: %s, Type: %s, ID: %d", i+1, getMentionAndNick(user), req.Type, req.ID)
+		if req.AssignmentName != "" {
+			fmt.Fprintf(&sb, ", Assignment: %s", req.AssignmentName)
+		}
+		if req.GroupName != "" {
+			fmt.Fprintf(&sb, ", Group: %s", req.GroupName)
+		}
+		sb.WriteString("\n")
+		if req.Description != "" {
+			fmt.Fprintf(&sb, "> %s\n", truncate(req.Description, 100))
+		}
+		members[req.StudentUserID] = user
 	}
-	replyMsg(bot.client, m, sb.String())
+	replyComponents(bot.client, m, sb.String(), claimMenu(requests, members))
 }
 
 func (bot *HelpBot) clearCommand(m *discordgo.InteractionCreate) {
-	data := m.ApplicationCommandData().Options
-	if len(data) < 1 || data[0].Type != discordgo.ApplicationCommandOptionBoolean {
+	confirm := getOption(m, "confirm")
+	if confirm == nil || confirm.Type != discordgo.ApplicationCommandOptionBoolean {
 		replyMsg(bot.client, m, "You must specify whether to clear the queue or not.")
 		return
 	}
 
-	if !data[0].BoolValue() {
+	if !confirm.BoolValue() {
 		replyMsg(bot.client, m, "No changes were made to the queue.")
 		return
 	}
 
-	// TODO: send a message to each student whose request was cleared.
-	if err := bot.db.ClearHelpRequests(m.Member.User.ID, m.GuildID); err != nil {
+	requests, err := bot.actorDB(m).ClearHelpRequests(m.Member.User.ID, m.GuildID)
+	if err != nil {
 		bot.log.Errorln("Failed to clear queue:", err)
 		replyMsg(bot.client, m, "Clear failed due to an error.")
 		return
 	}
+	bot.queueChanged(m.GuildID)
 
-	replyMsg(bot.client, m, "The queue was cleared.")
+	replyMsg(bot.client, m, fmt.Sprintf("The queue was cleared. %d students will be notified.", len(requests)))
+
+	msg := fmt.Sprintf("Your help request was removed from the queue by %s, who cleared the queue.", getMentionAndNick(m.Member))
+	if reason := getOption(m, "reason"); reason != nil {
+		msg += fmt.Sprintf("\nReason: %s", reason.StringValue())
+	}
+	for _, req := range requests {
+		for _, userID := range append([]string{req.StudentUserID}, req.GroupMemberIDs...) {
+			bot.dm.send(userID, msg)
+		}
+	}
 }
 
 func (bot *HelpBot) registerCommand(m *discordgo.InteractionCreate) {
@@ -277,37 +692,27 @@ func (bot *HelpBot) registerCommand(m *discordgo.InteractionCreate) {
 	// TODO: query autograder for real name, using github login for now
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
-	req := &qfpb.EnrollmentRequest{
-		FetchMode: &qfpb.EnrollmentRequest_CourseID{CourseID: uint64(course.CourseID)},
-	}
-	enrollments, err := bot.qf.qf.GetEnrollments(ctx, connect.NewRequest(req))
+	enrollment, err := bot.qf.GetEnrollment(ctx, course.CourseID, githubLogin)
 	if err != nil {
 		bot.log.Errorln("Failed to get info from QuickFeed:", err)
 		replyMsg(bot.client, m, "Failed to communicate with QuickFeed")
 		return
 	}
 
-	var enrollment *qfpb.Enrollment
-	for _, e := range enrollments.Msg.GetEnrollments() {
-		if e.GetUser().GetLogin() == githubLogin {
-			enrollment = e
-			break
-		}
-	}
-
 	if enrollment.GetUser() == nil {
 		replyMsg(bot.client, m, "Failed to find your enrollment in the course")
 		return
 	}
 
 	newStudent.Name = enrollment.GetUser().GetName()
+	newStudent.QuickFeedID = enrollment.GetUser().GetID()
 	newStudent.UserID = m.Member.User.ID
 	newStudent.GithubLogin = githubLogin
 	newStudent.GuildID = m.GuildID
 
 	switch enrollment.GetStatus() {
 	case qfpb.Enrollment_STUDENT:
-		if err := bot.db.CreateStudent(&newStudent); err != nil {
+		if err := bot.actorDB(m).CreateStudent(&newStudent); err != nil {
 			replyMsg(bot.client, m, "An uknown error occurred.")
 			return
 		}
@@ -353,7 +758,7 @@ func (bot *HelpBot) unregisterCommand(m *discordgo.InteractionCreate) {
 	user := m.Member.User
 
 	// permanent deletion from db
-	err := bot.db.DeleteStudent(&models.Student{UserID: user.ID})
+	err := bot.actorDB(m).DeleteStudent(&models.Student{UserID: user.ID})
 	if err != nil {
 		replyMsg(bot.client, m, "Failed to delete user info.")
 		bot.log.Errorln("Failed to delete student info:", err)
@@ -378,12 +783,13 @@ func (bot *HelpBot) unregisterCommand(m *discordgo.InteractionCreate) {
 }
 
 func (bot *HelpBot) assistantCancelCommand(m *discordgo.InteractionCreate) {
-	err := bot.db.CancelWaitingAssistant(m.Member.User.ID)
+	err := bot.actorDB(m).CancelWaitingAssistant(m.Member.User.ID, m.GuildID)
 	if err != nil {
 		replyMsg(bot.client, m, fmt.Sprintf("Failed to cancel waiting status: %v", err))
 		return
 	}
 	replyMsg(bot.client, m, "Your waiting status was removed (you will have to use /next again to get the next student)")
+	bot.queueChanged(m.GuildID)
 }
 
 // hasRole returns a function that checks if the user has the specified role, and then calls the original function.
@@ -423,7 +829,7 @@ func (bot *HelpBot) configureCommand(m *discordgo.InteractionCreate) {
 	}
 
 	course.GuildID = m.GuildID
-	if err := bot.db.UpdateCourse(course); err != nil {
+	if err := bot.actorDB(m).UpdateCourse(course); err != nil {
 		replyMsg(bot.client, m, fmt.Sprintf("Failed to update course: %v", err))
 		return
 	}
@@ -0,0 +1,112 @@
+package helpbot
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxSelectOptions is the maximum number of options in a select menu.
+const maxSelectOptions = 25
+
+// requestButtons returns the buttons shown to a student whose request is waiting in the queue.
+func requestButtons() discordgo.MessageComponent {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Refresh",
+				Style:    discordgo.SecondaryButton,
+				CustomID: componentRefresh,
+			},
+			discordgo.Button{
+				Label:    "Cancel request",
+				Style:    discordgo.DangerButton,
+				CustomID: componentCancel,
+			},
+		},
+	}
+}
+
+// refreshButton updates the student's request confirmation with their current position in the queue.
+func (bot *HelpBot) refreshButton(m *discordgo.InteractionCreate) {
+	msg, waiting := bot.statusMsg(m.GuildID, m.Member.User.ID)
+	if !waiting {
+		updateMsg(bot.client, m, msg)
+		return
+	}
+	updateMsg(bot.client, m, msg, requestButtons())
+}
+
+// cancelButton cancels the student's waiting request from the request confirmation.
+func (bot *HelpBot) cancelButton(m *discordgo.InteractionCreate) {
+	if err := bot.actorDB(m).CancelHelpRequest(m.GuildID, m.Member.User.ID); err != nil {
+		msg, _ := bot.statusMsg(m.GuildID, m.Member.User.ID)
+		updateMsg(bot.client, m, fmt.Sprintf("Your request could not be cancelled: %s\n%s", err, msg))
+		return
+	}
+	updateMsg(bot.client, m, "Your request was cancelled.")
+	bot.queueChanged(m.GuildID)
+}
+
+// boardButtons returns the buttons shown on the queue board.
+func boardButtons() discordgo.MessageComponent {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Take next",
+				Style:    discordgo.PrimaryButton,
+				CustomID: componentNext,
+			},
+		},
+	}
+}
+
+// claimMenu returns a select menu that lets an assistant take one of the requests.
+// members maps the students' user IDs to their guild members.
+func claimMenu(requests []*models.HelpRequest, members map[string]*discordgo.Member) discordgo.MessageComponent {
+	var options []discordgo.SelectMenuOption
+	for i, req := range requests {
+		if i == maxSelectOptions {
+			break
+		}
+		name := req.StudentUserID
+		if member, ok := members[req.StudentUserID]; ok {
+			name = member.User.Username
+			if member.Nick != "" {
+				name = member.Nick
+			}
+		}
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate(fmt.Sprintf("%d. %s: %s%s%s", i+1, name, req.Type, forAssignment(req), forGroup(req)), 100),
+			Value:       strconv.FormatUint(uint64(req.ID), 10),
+			Description: truncate(req.Description, 100),
+		})
+	}
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    componentClaim,
+				Placeholder: "Take a student from the queue",
+				Options:     options,
+			},
+		},
+	}
+}
+
+// claimSelect assigns the request selected in /list to the assistant.
+func (bot *HelpBot) claimSelect(m *discordgo.InteractionCreate) {
+	values := m.MessageComponentData().Values
+	if len(values) != 1 {
+		replyMsg(bot.client, m, "Invalid request.")
+		return
+	}
+	requestID, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		replyMsg(bot.client, m, "Invalid request.")
+		return
+	}
+	bot.takeRequest(m, uint(requestID))
+}
@@ -0,0 +1,273 @@
+package helpbot
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Raytar/helpbot/database"
+	"github.com/Raytar/helpbot/models"
+)
+
+// dashboardRefresh is how often the dashboard is refreshed when the queue does not change,
+// such that the estimated wait times and statistics stay up to date.
+const dashboardRefresh = 30 * time.Second
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// dashboardHub notifies the dashboards' event streams when the queue of their guild changes.
+type dashboardHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]bool
+}
+
+// subscribe returns a channel that receives a value when the guild's queue changes.
+func (h *dashboardHub) subscribe(guildID string) chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[string]map[chan struct{}]bool)
+	}
+	if h.subscribers[guildID] == nil {
+		h.subscribers[guildID] = make(map[chan struct{}]bool)
+	}
+	ch := make(chan struct{}, 1)
+	h.subscribers[guildID][ch] = true
+	return ch
+}
+
+func (h *dashboardHub) unsubscribe(guildID string, ch chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[guildID], ch)
+}
+
+// notify wakes up the guild's event streams. A stream that has not yet handled the previous change
+// is not notified again, since it sends the latest state of the queue anyway.
+func (h *dashboardHub) notify(guildID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[guildID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type dashboardShift struct {
+	UserID string `json:"userId"`
+	Since  string `json:"since"`
+}
+
+type dashboardStats struct {
+	UserID                string `json:"userId"`
+	Handled               int    `json:"handled"`
+	NoShows               int    `json:"noShows"`
+	AverageSessionSeconds int64  `json:"averageSessionSeconds"`
+	AverageWaitSeconds    int64  `json:"averageWaitSeconds"`
+}
+
+// dashboardRequest is a help request as shown on the dashboard. The dashboard may be shown on a screen
+// in the lab, so the student's description of the problem is left out.
+type dashboardRequest struct {
+	StudentID   string `json:"studentId"`
+	AssistantID string `json:"assistantId,omitempty"`
+	Type        string `json:"type"`
+	Assignment  string `json:"assignment,omitempty"`
+	Group       string `json:"group,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	AssignedAt  string `json:"assignedAt,omitempty"`
+}
+
+func newDashboardRequests(requests []*models.HelpRequest) []*dashboardRequest {
+	result := make([]*dashboardRequest, len(requests))
+	for i, req := range requests {
+		result[i] = &dashboardRequest{
+			StudentID:   req.StudentUserID,
+			AssistantID: req.AssistantUserID,
+			Type:        req.Type,
+			Assignment:  req.AssignmentName,
+			Group:       req.GroupName,
+			CreatedAt:   req.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if !req.AssignedAt.IsZero() {
+			result[i].AssignedAt = req.AssignedAt.UTC().Format(time.RFC3339)
+		}
+	}
+	return result
+}
+
+// dashboardState is the state of a guild's queue that is sent to the dashboard.
+type dashboardState struct {
+	Course     string              `json:"course"`
+	QueueState string              `json:"queueState"`
+	Waiting    []*dashboardRequest `json:"waiting"`
+	Sessions   []*dashboardRequest `json:"sessions"`
+	OnDuty     []*dashboardShift   `json:"onDuty"`
+	// EstimatedWaitSeconds is the estimated wait time of a student who joins the queue now.
+	EstimatedWaitSeconds int64             `json:"estimatedWaitSeconds"`
+	Today                []*dashboardStats `json:"today"`
+	// Names maps the user IDs in the state to their display names in the guild.
+	Names     map[string]string `json:"names"`
+	UpdatedAt string            `json:"updatedAt"`
+}
+
+// dashboardState returns the current state of the guild's queue, read from the same tables as /list.
+func (bot *HelpBot) dashboardState(guildID string) (*dashboardState, error) {
+	course, err := bot.db.GetCourse(&models.Course{GuildID: guildID})
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := bot.db.GetWaitingRequests(guildID, 0)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := bot.db.GetSessions(guildID)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := bot.db.GetOnDuty(guildID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stats, err := bot.db.GetAssistantStats(guildID, periodStart("today", now, bot.location))
+	if err != nil {
+		return nil, err
+	}
+	wait, err := bot.estimateWaitAt(guildID, len(waiting)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &dashboardState{
+		Course:               course.Name,
+		QueueState:           string(course.QueueState),
+		Waiting:              newDashboardRequests(waiting),
+		Sessions:             newDashboardRequests(sessions),
+		OnDuty:               make([]*dashboardShift, len(shifts)),
+		EstimatedWaitSeconds: int64(wait.Seconds()),
+		Today:                make([]*dashboardStats, len(stats)),
+		Names:                make(map[string]string),
+		UpdatedAt:            now.UTC().Format(time.RFC3339),
+	}
+	for i, shift := range shifts {
+		state.OnDuty[i] = &dashboardShift{UserID: shift.UserID, Since: shift.StartedAt.UTC().Format(time.RFC3339)}
+		state.Names[shift.UserID] = bot.displayName(guildID, shift.UserID)
+	}
+	for i, s := range stats {
+		state.Today[i] = newDashboardStats(s)
+		state.Names[s.UserID] = bot.displayName(guildID, s.UserID)
+	}
+	for _, req := range append(state.Waiting, state.Sessions...) {
+		state.Names[req.StudentID] = bot.displayName(guildID, req.StudentID)
+		if req.AssistantID != "" {
+			state.Names[req.AssistantID] = bot.displayName(guildID, req.AssistantID)
+		}
+	}
+	return state, nil
+}
+
+func newDashboardStats(s *database.AssistantStats) *dashboardStats {
+	return &dashboardStats{
+		UserID:                s.UserID,
+		Handled:               s.Handled,
+		NoShows:               s.NoShows,
+		AverageSessionSeconds: int64(s.AverageSession.Seconds()),
+		AverageWaitSeconds:    int64(s.AverageWait.Seconds()),
+	}
+}
+
+// displayName returns the nickname or username of the guild member from the session's cache,
+// or the user ID if the member is not cached.
+func (bot *HelpBot) displayName(guildID, userID string) string {
+	if bot.client == nil {
+		return userID
+	}
+	member, err := bot.client.State.Member(guildID, userID)
+	if err != nil || member.User == nil {
+		return userID
+	}
+	if member.Nick != "" {
+		return member.Nick
+	}
+	return member.User.Username
+}
+
+// handleDashboard registers the dashboard's handlers. The dashboard of a guild is served at
+// /dashboard/<guild id>?token=<dashboard_token>, since a browser cannot set the authorization header.
+// The dashboard token is separate from the API token, since it ends up in browser histories and server logs.
+func (bot *HelpBot) handleDashboard(mux *http.ServeMux) {
+	if bot.cfg.DashboardToken == "" {
+		return
+	}
+	mux.HandleFunc("GET /dashboard/{guildID}", bot.authorizeDashboard(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(dashboardHTML)
+	}))
+	mux.HandleFunc("GET /dashboard/{guildID}/events", bot.authorizeDashboard(bot.dashboardEvents))
+}
+
+func (bot *HelpBot) authorizeDashboard(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if token := r.URL.Query().Get("token"); token != "" {
+			authorization = "Bearer " + token
+		}
+		if !validToken(authorization, bot.cfg.DashboardToken) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// dashboardEvents streams the state of the guild's queue as server-sent events, whenever the queue changes.
+func (bot *HelpBot) dashboardEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	guildID := r.PathValue("guildID")
+	if _, err := bot.db.GetCourse(&models.Course{GuildID: guildID}); err != nil {
+		http.Error(w, "no course is configured for the server", http.StatusNotFound)
+		return
+	}
+
+	changed := bot.dashboard.subscribe(guildID)
+	defer bot.dashboard.unsubscribe(guildID, changed)
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for {
+		state, err := bot.dashboardState(guildID)
+		if err != nil {
+			bot.log.Errorln("Failed to get dashboard state:", err)
+			return
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			bot.log.Errorln("Failed to encode dashboard state:", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
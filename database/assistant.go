@@ -7,10 +7,10 @@ import (
 	"gorm.io/gorm"
 )
 
-func (db *Database) CancelWaitingAssistant(assistantID string) error {
+func (db *Database) CancelWaitingAssistant(assistantID, guildID string) error {
 	return db.conn.Transaction(func(tx *gorm.DB) error {
 		var assistant models.Assistant
-		err := tx.Model(assistant).Where("user_id = ?", assistantID).First(&assistant).Error
+		err := tx.Model(assistant).Where("user_id = ? AND guild_id = ?", assistantID, guildID).First(&assistant).Error
 		if err != nil {
 			db.log.Errorln("Failed to get assistant from DB:", err)
 			return fmt.Errorf("an unknown error occurred when attempting to get assistant from DB")
@@ -20,7 +20,7 @@ func (db *Database) CancelWaitingAssistant(assistantID string) error {
 			return fmt.Errorf("you were not marked as waiting, so no action was taken")
 		}
 
-		err = tx.Model(assistant).Where("user_id = ?", assistantID).UpdateColumn("waiting", false).Error
+		err = tx.Model(assistant).Where("user_id = ? AND guild_id = ?", assistantID, guildID).UpdateColumn("waiting", false).Error
 		if err != nil {
 			db.log.Errorln("Failed to update status in DB:", err)
 			return fmt.Errorf("an unknown error occurred when attempting to update waiting status")
@@ -36,3 +36,11 @@ func (db *Database) GetOrCreateAssistant(assistant *models.Assistant) (a *models
 	}
 	return assistant, nil
 }
+
+// GetWaitingAssistants returns the assistants in the guild that are waiting for a request, ordered by how long they have waited.
+func (db *Database) GetWaitingAssistants(guildID string) (assistants []*models.Assistant, err error) {
+	if err = db.conn.Where("guild_id = ? AND waiting = ?", guildID, true).Order("waiting_since asc").Find(&assistants).Error; err != nil {
+		db.log.Errorln("Failed to get waiting assistants from DB:", err)
+	}
+	return
+}
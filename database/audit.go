@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditedTables maps the tables whose changes are recorded in the audit log
+// to the column that identifies the user affected by a change, if any.
+var auditedTables = map[string]string{
+	"help_requests": "student_user_id",
+	"students":      "user_id",
+	"courses":       "",
+}
+
+// auditRowsKey is the statement's instance key of the records that are about to be updated or deleted.
+const auditRowsKey = "audit:rows"
+
+// APIActor is the actor of the changes made through the queue API.
+const APIActor = "api"
+
+// actorKey is the context key of the user who makes the changes.
+type actorKey struct{}
+
+// WithActor returns the database with the user recorded as the actor of the changes made through it.
+func (db *Database) WithActor(userID string) *Database {
+	actor := *db
+	actor.conn = db.conn.WithContext(context.WithValue(db.conn.Statement.Context, actorKey{}, userID))
+	return &actor
+}
+
+// auditRow identifies a changed record.
+type auditRow struct {
+	id      string
+	guildID string
+	userID  string
+}
+
+// registerAuditCallbacks records every change to the audited tables in the audit log.
+// The entries are created in the same transaction as the change, such that they are rolled back with it.
+func (db *Database) registerAuditCallbacks() error {
+	callbacks := db.conn.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("audit:create", db.auditChange("create")); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", db.auditedRows); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:update", db.auditChange("update")); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", db.auditedRows); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:delete", db.auditChange("delete"))
+}
+
+// auditedRows looks up the records that the update or delete statement is about to change,
+// since the conditions of the statement may no longer match them afterwards.
+func (db *Database) auditedRows(tx *gorm.DB) {
+	stmt := tx.Statement
+	userColumn, ok := auditedTables[stmt.Table]
+	if !ok || stmt.Schema == nil || tx.Error != nil {
+		return
+	}
+
+	query := tx.Session(&gorm.Session{NewDB: true}).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		query = query.Unscoped()
+	}
+	conditions := false
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+		query = query.Clauses(where)
+		conditions = true
+	}
+	// The primary key of the model is added to the conditions by the update or delete itself
+	if value := reflect.Indirect(stmt.ReflectValue); value.Kind() == reflect.Struct {
+		for _, field := range stmt.Schema.PrimaryFields {
+			if v, zero := field.ValueOf(stmt.Context, value); !zero {
+				query = query.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: v})
+				conditions = true
+			}
+		}
+	}
+	if !conditions {
+		return
+	}
+
+	columns := []string{stmt.Schema.PrioritizedPrimaryField.DBName, "guild_id"}
+	if userColumn != "" {
+		columns = append(columns, userColumn)
+	}
+	var results []map[string]any
+	if err := query.Select(columns).Find(&results).Error; err != nil {
+		db.log.Errorln("Failed to get records for the audit log:", err)
+		return
+	}
+	rows := make([]auditRow, len(results))
+	for i, result := range results {
+		rows[i] = auditRow{id: fmt.Sprint(result[columns[0]]), guildID: fmt.Sprint(result["guild_id"])}
+		if userColumn != "" {
+			rows[i].userID = fmt.Sprint(result[userColumn])
+		}
+	}
+	tx.InstanceSet(auditRowsKey, rows)
+}
+
+// createdRows returns the records that were created by the statement.
+func createdRows(tx *gorm.DB) []auditRow {
+	stmt := tx.Statement
+	userColumn := auditedTables[stmt.Table]
+	var values []reflect.Value
+	switch value := reflect.Indirect(stmt.ReflectValue); value.Kind() {
+	case reflect.Struct:
+		values = append(values, value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			values = append(values, reflect.Indirect(value.Index(i)))
+		}
+	}
+
+	valueOf := func(column string, value reflect.Value) string {
+		if field := stmt.Schema.LookUpField(column); column != "" && field != nil {
+			v, _ := field.ValueOf(stmt.Context, value)
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	rows := make([]auditRow, len(values))
+	for i, value := range values {
+		rows[i] = auditRow{
+			id:      valueOf(stmt.Schema.PrioritizedPrimaryField.DBName, value),
+			guildID: valueOf("guild_id", value),
+			userID:  valueOf(userColumn, value),
+		}
+	}
+	return rows
+}
+
+// changedColumns returns the names of the columns that the create or update statement set, without their values,
+// since the values may be personal information such as the description of a help request.
+func changedColumns(stmt *gorm.Statement) []string {
+	if values, ok := stmt.Clauses["VALUES"].Expression.(clause.Values); ok {
+		columns := make([]string, len(values.Columns))
+		for i, column := range values.Columns {
+			columns[i] = column.Name
+		}
+		return columns
+	}
+
+	// The SET clause of an update is removed once the update is done, so the columns are found from its destination
+	var columns []string
+	switch dest := stmt.Dest.(type) {
+	case map[string]any:
+		for name := range dest {
+			if field := stmt.Schema.LookUpField(name); field != nil {
+				name = field.DBName
+			}
+			columns = append(columns, name)
+		}
+		slices.Sort(columns)
+	default:
+		value := reflect.Indirect(reflect.ValueOf(dest))
+		if value.Kind() != reflect.Struct || value.Type() != stmt.Schema.ModelType {
+			break
+		}
+		// Save updates every column, while Updates only updates the non-zero fields
+		all := slices.Contains(stmt.Selects, "*")
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || field.PrimaryKey {
+				continue
+			}
+			if _, zero := field.ValueOf(stmt.Context, value); all || !zero {
+				columns = append(columns, field.DBName)
+			}
+		}
+	}
+	return columns
+}
+
+// auditChange returns a callback that records the changed records of the audited tables.
+func (db *Database) auditChange(action string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		stmt := tx.Statement
+		if _, ok := auditedTables[stmt.Table]; !ok || stmt.Schema == nil || tx.Error != nil || tx.RowsAffected == 0 {
+			return
+		}
+
+		var rows []auditRow
+		if action == "create" {
+			rows = createdRows(tx)
+		} else if v, ok := tx.InstanceGet(auditRowsKey); ok {
+			rows = v.([]auditRow)
+		}
+		if len(rows) == 0 {
+			return
+		}
+
+		var details string
+		if action != "delete" {
+			details = strings.Join(changedColumns(stmt), " ")
+		}
+		actorID, _ := stmt.Context.Value(actorKey{}).(string)
+		entries := make([]*models.AuditEntry, len(rows))
+		for i, row := range rows {
+			entries[i] = &models.AuditEntry{
+				GuildID: row.guildID,
+				UserID:  row.userID,
+				ActorID: actorID,
+				Kind:    models.AuditChange,
+				Action:  action,
+				Target:  stmt.Table + "/" + row.id,
+				Details: details,
+			}
+		}
+		if err := tx.Session(&gorm.Session{NewDB: true, SkipDefaultTransaction: true}).Create(entries).Error; err != nil {
+			db.log.Errorln("Failed to record changes in the audit log:", err)
+		}
+	}
+}
+
+// CreateAuditEntry adds the entry to the audit log.
+func (db *Database) CreateAuditEntry(entry *models.AuditEntry) error {
+	if err := db.conn.Create(entry).Error; err != nil {
+		db.log.Errorln("Failed to create audit entry:", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteAuditEntriesBefore permanently deletes the audit log entries created before t, and returns the number of deleted entries.
+func (db *Database) DeleteAuditEntriesBefore(t time.Time) (int64, error) {
+	result := db.conn.Unscoped().Where("created_at < ?", t).Delete(&models.AuditEntry{})
+	if result.Error != nil {
+		db.log.Errorln("Failed to delete audit entries:", result.Error)
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// GetAuditEntries returns the guild's latest num audit log entries for the user, newest first.
+// The entries include the commands used by the user, the changes made by the user, and the changes to the user's requests
+// and registration. The changes to requests made on behalf of the user's group are included as well.
+func (db *Database) GetAuditEntries(guildID, userID string, num int) (entries []*models.AuditEntry, err error) {
+	// The changes to a group's request are recorded for the student who made it
+	groupRequests := db.conn.Unscoped().Model(&models.HelpRequest{}).Select("'help_requests/' || id").
+		Where("guild_id = ? AND group_member_ids LIKE ?", guildID, fmt.Sprintf("%%%q%%", userID))
+	err = db.conn.Where("guild_id = ? AND (user_id = ? OR actor_id = ? OR target IN (?))", guildID, userID, userID, groupRequests).
+		Order("id desc").Limit(num).Find(&entries).Error
+	if err != nil {
+		db.log.Errorln("Failed to get audit entries:", err)
+	}
+	return entries, err
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/Raytar/helpbot/models"
 	"github.com/quickfeed/quickfeed/qf"
@@ -52,3 +53,38 @@ func (db *Database) GetCourses() ([]*models.Course, error) {
 	}
 	return courses, nil
 }
+
+// SetBoard records the queue board message of the course configured for the guild.
+func (db *Database) SetBoard(guildID, channelID, messageID string) error {
+	return db.conn.Model(&models.Course{}).Where("guild_id = ?", guildID).Updates(map[string]any{
+		"board_channel_id": channelID,
+		"board_message_id": messageID,
+	}).Error
+}
+
+// SetIgnoreScoreLimit sets whether approval requests in the guild are accepted regardless of the assignment's score limit.
+func (db *Database) SetIgnoreScoreLimit(guildID string, ignore bool) error {
+	result := db.conn.Model(&models.Course{}).Where("guild_id = ?", guildID).Update("ignore_score_limit", ignore)
+	if result.Error != nil {
+		db.log.Errorln("Failed to update score limit setting:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("this server is not configured with a course")
+	}
+	return nil
+}
+
+// SetSessionChannel sets the channel where the guild's session threads are created.
+// An empty channel ID creates the threads in the channel where the session is started.
+func (db *Database) SetSessionChannel(guildID, channelID string) error {
+	result := db.conn.Model(&models.Course{}).Where("guild_id = ?", guildID).Update("session_channel_id", channelID)
+	if result.Error != nil {
+		db.log.Errorln("Failed to update session channel:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("this server is not configured with a course")
+	}
+	return nil
+}
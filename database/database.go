@@ -2,6 +2,7 @@ package database
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Raytar/helpbot/models"
 	"github.com/sirupsen/logrus"
@@ -9,9 +10,17 @@ import (
 	"gorm.io/gorm"
 )
 
+// offsetMargin widens the time bounds of queries on timestamp columns. The timestamps are stored as text
+// that includes the UTC offset, and do not sort as strings when the offset changes, e.g., with daylight
+// saving time. The offsets differ by less than a day, so a widened bound only includes extra rows,
+// and the exact comparison is done in Go.
+const offsetMargin = 24 * time.Hour
+
 type Database struct {
 	conn *gorm.DB
 	log  *logrus.Logger
+	// location is the timezone of the lab hours, used to find the start of the day
+	location *time.Location
 }
 
 func OpenDatabase(path string, logger *logrus.Logger) (*Database, error) {
@@ -31,9 +40,23 @@ func OpenDatabase(path string, logger *logrus.Logger) (*Database, error) {
 		&models.Student{},
 		&models.Assistant{},
 		&models.HelpRequest{},
+		&models.Grade{},
 		&models.Course{},
+		&models.LabHours{},
+		&models.Lane{},
+		&models.Shift{},
+		&models.AuditEntry{},
 	)
-	return &Database{db, logger}, nil
+	database := &Database{db, logger, time.Local}
+	if err := database.registerAuditCallbacks(); err != nil {
+		return nil, err
+	}
+	return database, nil
+}
+
+// SetLocation sets the timezone of the lab hours. The default is the local timezone.
+func (db *Database) SetLocation(location *time.Location) {
+	db.location = location
 }
 
 func (db *Database) Close() error {
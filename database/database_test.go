@@ -0,0 +1,489 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/sirupsen/logrus"
+)
+
+func TestAutoAssignWaitingAssistant(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	for _, assistantID := range []string{"ta1", "ta2"} {
+		req, _, err := db.AssignNextRequest(assistantID, "1", "")
+		if err != nil {
+			t.Fatalf("AssignNextRequest(%s) failed: %v", assistantID, err)
+		}
+		if req != nil {
+			t.Fatalf("AssignNextRequest(%s) = %+v, want nil", assistantID, req)
+		}
+	}
+
+	// requests are handed to the assistant that has waited the longest
+	for _, want := range []string{"ta1", "ta2", ""} {
+		req := &models.HelpRequest{StudentUserID: "s" + want, GuildID: "1", Type: "help"}
+		if err := db.CreateHelpRequest(req); err != nil {
+			t.Fatalf("CreateHelpRequest failed: %v", err)
+		}
+		if req.AssistantUserID != want {
+			t.Errorf("CreateHelpRequest assigned %q, want %q", req.AssistantUserID, want)
+		}
+	}
+
+	if pos, err := db.GetQueuePosition("1", "s"); err != nil || pos != 1 {
+		t.Errorf("GetQueuePosition() = %d, %v, want 1", pos, err)
+	}
+}
+
+func TestHelpRequestLifecycle(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "1", GuildID: "1", Type: "help"})
+	db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "2", GuildID: "1", Type: "help"})
+
+	req, _, err := db.AssignNextRequest("ta", "1", "")
+	if err != nil || req == nil {
+		t.Fatalf("AssignNextRequest() = %v, %v", req, err)
+	}
+	if req.StudentUserID != "1" || req.Status != models.StatusInProgress || req.Done {
+		t.Errorf("AssignNextRequest() = %+v, want student 1 in progress", req)
+	}
+	if err := db.CancelHelpRequest("1", "1"); err == nil {
+		t.Error("CancelHelpRequest() succeeded for a request in progress")
+	}
+	if active, err := db.GetActiveRequest("1", "1"); err != nil || active == nil || active.AssistantUserID != "ta" {
+		t.Errorf("GetActiveRequest() = %+v, %v, want request assigned to ta", active, err)
+	}
+
+	// taking the next request resolves the current session
+	req, resolved, err := db.AssignNextRequest("ta", "1", "")
+	if err != nil || req == nil || req.StudentUserID != "2" {
+		t.Fatalf("AssignNextRequest() = %+v, %v, want student 2", req, err)
+	}
+	if resolved == nil || resolved.StudentUserID != "1" || resolved.Status != models.StatusResolved {
+		t.Errorf("AssignNextRequest() closed %+v, want student 1 resolved", resolved)
+	}
+	if active, err := db.GetActiveRequest("1", "1"); err != nil || active != nil {
+		t.Errorf("GetActiveRequest() = %+v, %v, want nil", active, err)
+	}
+
+	closed, err := db.CloseSession("ta", "1", models.StatusNoShow)
+	if err != nil || closed == nil {
+		t.Fatalf("CloseSession() = %v, %v", closed, err)
+	}
+	if closed.StudentUserID != "2" || closed.Status != models.StatusNoShow || !closed.Done || closed.DoneAt.Before(closed.AssignedAt) {
+		t.Errorf("CloseSession() = %+v, want student 2 closed as no-show", closed)
+	}
+	if closed, err := db.CloseSession("ta", "1", models.StatusResolved); err != nil || closed != nil {
+		t.Errorf("CloseSession() = %+v, %v, want nil", closed, err)
+	}
+}
+
+func TestSetSubmissionStatus(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "1", GuildID: "1", Type: "approve", AssignmentID: 1}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	req, _, err := db.AssignNextRequest("ta", "1", "")
+	if err != nil || req == nil {
+		t.Fatalf("AssignNextRequest() = %v, %v", req, err)
+	}
+	for _, status := range []string{"REVISION", "APPROVED"} {
+		if err := db.SetSubmissionStatus(req, 10, status); err != nil {
+			t.Fatalf("SetSubmissionStatus(%s) failed: %v", status, err)
+		}
+	}
+	if req.SubmissionID != 10 || req.SubmissionStatus != "APPROVED" {
+		t.Errorf("SetSubmissionStatus() = %+v, want the latest status", req)
+	}
+
+	// every status that was set is kept
+	grades, err := db.GetGrades(req.ID)
+	if err != nil || len(grades) != 2 {
+		t.Fatalf("GetGrades() = %v, %v, want 2 grades", grades, err)
+	}
+	for i, want := range []string{"REVISION", "APPROVED"} {
+		if g := grades[i]; g.Status != want || g.AssistantUserID != "ta" || g.SubmissionID != 10 || g.CreatedAt.IsZero() {
+			t.Errorf("GetGrades()[%d] = %+v, want %s by ta", i, g, want)
+		}
+	}
+}
+
+func TestAssignRequest(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	first := &models.HelpRequest{StudentUserID: "1", GuildID: "1", Type: "help"}
+	second := &models.HelpRequest{StudentUserID: "2", GuildID: "1", Type: "help"}
+	db.CreateHelpRequest(first)
+	db.CreateHelpRequest(second)
+
+	// requests can be taken out of order
+	req, _, err := db.AssignRequest("ta1", "1", second.ID)
+	if err != nil || req.StudentUserID != "2" || req.AssistantUserID != "ta1" || req.Status != models.StatusInProgress {
+		t.Fatalf("AssignRequest() = %+v, %v, want student 2 assigned to ta1", req, err)
+	}
+
+	// a request cannot be taken twice, and the current session is kept
+	if _, _, err := db.AssignNextRequest("ta2", "1", ""); err != nil {
+		t.Fatalf("AssignNextRequest() failed: %v", err)
+	}
+	if req, _, err := db.AssignRequest("ta1", "1", first.ID); err == nil {
+		t.Errorf("AssignRequest() = %+v, want error for request in progress", req)
+	}
+	if session, err := db.GetSession("ta1", "1"); err != nil || session == nil || session.StudentUserID != "2" {
+		t.Errorf("GetSession() = %+v, %v, want student 2", session, err)
+	}
+	if _, _, err := db.AssignRequest("ta1", "2", second.ID); err == nil {
+		t.Error("AssignRequest() succeeded for a request in another guild")
+	}
+}
+
+func TestGroupHelpRequests(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "1", GuildID: "1", GroupID: 1, GroupMemberIDs: []string{"2"}}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "2", GuildID: "1", GroupID: 1}); err == nil {
+		t.Error("CreateHelpRequest succeeded for a group with an active request")
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "2", GuildID: "1"}); err == nil {
+		t.Error("CreateHelpRequest succeeded for a member of a group with an active request")
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "3", GuildID: "1", GroupID: 2}); err != nil {
+		t.Errorf("CreateHelpRequest failed for another group: %v", err)
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "4", GuildID: "1", GroupID: 3, GroupMemberIDs: []string{"3"}}); err == nil {
+		t.Error("CreateHelpRequest succeeded for a group with a member with an active request")
+	}
+
+	if pos, err := db.GetQueuePosition("1", "2"); err != nil || pos != 1 {
+		t.Errorf("GetQueuePosition() = %d, %v, want 1 for a group member", pos, err)
+	}
+	if err := db.UpdateDescription("1", "2", "stuck", ""); err != nil {
+		t.Errorf("UpdateDescription() failed for a group member: %v", err)
+	}
+
+	req, _, err := db.AssignNextRequest("ta", "1", "")
+	if err != nil || req == nil {
+		t.Fatalf("AssignNextRequest() = %v, %v", req, err)
+	}
+	if len(req.GroupMemberIDs) != 1 || req.GroupMemberIDs[0] != "2" || req.Description != "stuck" {
+		t.Errorf("AssignNextRequest() = %+v, want group member 2 and description stuck", req)
+	}
+	if active, err := db.GetActiveRequest("1", "2"); err != nil || active == nil || active.ID != req.ID {
+		t.Errorf("GetActiveRequest() = %+v, %v, want the group's request", active, err)
+	}
+
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "5", GuildID: "1", GroupID: 4, GroupMemberIDs: []string{"6"}}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if err := db.CancelHelpRequest("1", "6"); err != nil {
+		t.Errorf("CancelHelpRequest() failed for a group member: %v", err)
+	}
+}
+
+func TestLanes(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	now := time.Now()
+	create := func(studentID, requestType string, age time.Duration) {
+		t.Helper()
+		req := &models.HelpRequest{StudentUserID: studentID, GuildID: "1", Type: requestType}
+		req.CreatedAt = now.Add(-age)
+		if err := db.CreateHelpRequest(req); err != nil {
+			t.Fatalf("CreateHelpRequest failed: %v", err)
+		}
+	}
+	next := func(lane, want string) {
+		t.Helper()
+		req, _, err := db.AssignNextRequest("ta", "1", lane)
+		if err != nil {
+			t.Fatalf("AssignNextRequest(%q) failed: %v", lane, err)
+		}
+		var got string
+		if req != nil {
+			got = req.StudentUserID
+		}
+		if got != want {
+			t.Errorf("AssignNextRequest(%q) assigned %q, want %q", lane, got, want)
+		}
+	}
+
+	// without lanes, requests are taken in the order they were made
+	create("h1", "help", 10*time.Minute)
+	create("a1", "approve", 6*time.Minute)
+	next("", "h1")
+
+	// a weighted lane moves up faster than lanes with the same priority
+	create("h2", "help", 10*time.Minute)
+	if err := db.SetLane(&models.Lane{GuildID: "1", Name: "approve", Weight: 2}); err != nil {
+		t.Fatalf("SetLane failed: %v", err)
+	}
+	// the positions and the waiting requests are in the order the requests will be taken
+	for studentID, want := range map[string]int{"a1": 1, "h2": 2} {
+		if pos, err := db.GetQueuePosition("1", studentID); err != nil || pos != want {
+			t.Errorf("GetQueuePosition(%s) = %d, %v, want %d", studentID, pos, err, want)
+		}
+	}
+	if waiting, err := db.GetWaitingRequests("1", 1); err != nil || len(waiting) != 1 || waiting[0].StudentUserID != "a1" {
+		t.Errorf("GetWaitingRequests() = %v, %v, want a1", waiting, err)
+	}
+	next("", "a1")
+
+	// a lane with higher priority is always taken first
+	create("a2", "approve", time.Minute)
+	if err := db.SetLane(&models.Lane{GuildID: "1", Name: "help", Priority: 1, Weight: 1}); err != nil {
+		t.Fatalf("SetLane failed: %v", err)
+	}
+	next("", "h2")
+	if counts, err := db.CountWaitingByLane("1"); err != nil || counts["approve"] != 1 || counts["help"] != 0 {
+		t.Errorf("CountWaitingByLane() = %v, %v, want approve: 1", counts, err)
+	}
+
+	// an assistant waiting for a lane is only assigned requests in that lane
+	next("help", "")
+	create("a3", "approve", 0)
+	if active, err := db.GetActiveRequest("1", "a3"); err != nil || active.Status != models.StatusWaiting {
+		t.Errorf("GetActiveRequest() = %+v, %v, want waiting request", active, err)
+	}
+	create("h3", "help", 0)
+	if active, err := db.GetActiveRequest("1", "h3"); err != nil || active.AssistantUserID != "ta" {
+		t.Errorf("GetActiveRequest() = %+v, %v, want request assigned to ta", active, err)
+	}
+}
+
+func TestShifts(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	for _, userID := range []string{"ta1", "ta2"} {
+		if _, err := db.StartShift("1", userID); err != nil {
+			t.Fatalf("StartShift(%s) failed: %v", userID, err)
+		}
+	}
+	if _, err := db.StartShift("1", "ta1"); err == nil {
+		t.Error("StartShift() succeeded for an assistant on duty")
+	}
+	if shifts, err := db.GetOnDuty("1"); err != nil || len(shifts) != 2 {
+		t.Errorf("GetOnDuty() = %v, %v, want 2 shifts", shifts, err)
+	}
+
+	// assistants that go off duty are no longer waiting for requests
+	if _, _, err := db.AssignNextRequest("ta1", "1", ""); err != nil {
+		t.Fatalf("AssignNextRequest() failed: %v", err)
+	}
+	shift, err := db.EndShift("1", "ta1")
+	if err != nil || shift.OnDuty || shift.Reason != "offDuty" {
+		t.Fatalf("EndShift() = %+v, %v, want ended shift", shift, err)
+	}
+	if _, err := db.EndShift("1", "ta1"); err == nil {
+		t.Error("EndShift() succeeded for an assistant off duty")
+	}
+	if waiting, err := db.GetWaitingAssistants("1"); err != nil || len(waiting) != 0 {
+		t.Errorf("GetWaitingAssistants() = %v, %v, want none", waiting, err)
+	}
+
+	if shifts, err := db.EndShiftsStartedBefore("1", time.Now().Add(time.Hour), "labHoursEnded"); err != nil || len(shifts) != 1 || shifts[0].UserID != "ta2" {
+		t.Errorf("EndShiftsStartedBefore() = %v, %v, want ta2", shifts, err)
+	}
+	if shifts, err := db.GetOnDuty("1"); err != nil || len(shifts) != 0 {
+		t.Errorf("GetOnDuty() = %v, %v, want none", shifts, err)
+	}
+
+	// shifts that have lasted too long are ended in every guild
+	for _, guildID := range []string{"1", "2"} {
+		if _, err := db.StartShift(guildID, "ta1"); err != nil {
+			t.Fatalf("StartShift(%s) failed: %v", guildID, err)
+		}
+	}
+	if shifts, err := db.EndShiftsStartedBefore("", time.Now().Add(-time.Hour), "maxShiftLength"); err != nil || len(shifts) != 0 {
+		t.Errorf("EndShiftsStartedBefore() = %v, %v, want none", shifts, err)
+	}
+	if shifts, err := db.EndShiftsStartedBefore("", time.Now().Add(time.Hour), "maxShiftLength"); err != nil || len(shifts) != 2 {
+		t.Errorf("EndShiftsStartedBefore() = %v, %v, want 2 shifts", shifts, err)
+	}
+	if shifts, err := db.GetOnDuty("2"); err != nil || len(shifts) != 0 {
+		t.Errorf("GetOnDuty() = %v, %v, want none", shifts, err)
+	}
+}
+
+func TestOnDutyAssistants(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	for _, assistantID := range []string{"ta1", "ta2"} {
+		if _, _, err := db.AssignNextRequest(assistantID, "1", ""); err != nil {
+			t.Fatalf("AssignNextRequest(%s) failed: %v", assistantID, err)
+		}
+	}
+	if _, err := db.StartShift("1", "ta2"); err != nil {
+		t.Fatalf("StartShift failed: %v", err)
+	}
+	create := func(studentID, want string) {
+		t.Helper()
+		req := &models.HelpRequest{StudentUserID: studentID, GuildID: "1", Type: "help"}
+		if err := db.CreateHelpRequest(req); err != nil {
+			t.Fatalf("CreateHelpRequest failed: %v", err)
+		}
+		if req.AssistantUserID != want {
+			t.Errorf("CreateHelpRequest assigned %q, want %q", req.AssistantUserID, want)
+		}
+	}
+
+	// ta1 has waited the longest, but only ta2 is on duty
+	create("s1", "ta2")
+	if _, err := db.StartShift("1", "ta3"); err != nil {
+		t.Fatalf("StartShift failed: %v", err)
+	}
+	create("s2", "")
+	if err := db.CancelHelpRequest("1", "s2"); err != nil {
+		t.Fatalf("CancelHelpRequest failed: %v", err)
+	}
+
+	// without open shifts, every waiting assistant is a candidate
+	if _, err := db.EndShiftsStartedBefore("1", time.Now().Add(time.Hour), "offDuty"); err != nil {
+		t.Fatalf("EndShiftsStartedBefore failed: %v", err)
+	}
+	if _, err := db.CloseSession("ta2", "1", models.StatusResolved); err != nil {
+		t.Fatalf("CloseSession failed: %v", err)
+	}
+	create("s3", "ta1")
+}
+
+func TestAssignmentStrategies(t *testing.T) {
+	tests := []struct {
+		strategy models.AssignmentStrategy
+		want     string
+	}{
+		// ta2 has waited the longest
+		{models.StrategyFIFO, "ta2"},
+		// ta1 was least recently assigned a request
+		{models.StrategyRoundRobin, "ta1"},
+		// ta3 had one session today, the others had two
+		{models.StrategyLeastLoaded, "ta3"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.strategy), func(t *testing.T) {
+			db := setupTestDatabase(t)
+			defer db.Close()
+
+			if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "1"}); err != nil {
+				t.Fatalf("CreateCourse failed: %v", err)
+			}
+			if err := db.SetAssignmentStrategy("1", test.strategy); err != nil {
+				t.Fatalf("SetAssignmentStrategy failed: %v", err)
+			}
+
+			year, month, day := time.Now().Date()
+			today := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+			// ta1's sessions are stored with another UTC offset, as if the daylight saving time changed
+			otherOffset := time.FixedZone("", 14*60*60)
+			sessions := []struct {
+				assistantID string
+				assignedAt  time.Time
+			}{
+				{"ta3", today.Add(-time.Hour)},
+				{"ta1", today.Add(1 * time.Second).In(otherOffset)},
+				{"ta1", today.Add(2 * time.Second).In(otherOffset)},
+				{"ta2", today.Add(3 * time.Second)},
+				{"ta3", today.Add(4 * time.Second)},
+				{"ta2", today.Add(5 * time.Second)},
+			}
+			for i, session := range sessions {
+				req := &models.HelpRequest{StudentUserID: fmt.Sprintf("s%d", i), GuildID: "1", Type: "help", Done: true, AssistantUserID: session.assistantID, AssignedAt: session.assignedAt}
+				if err := db.CreateHelpRequest(req); err != nil {
+					t.Fatalf("CreateHelpRequest failed: %v", err)
+				}
+			}
+			for _, assistantID := range []string{"ta2", "ta3", "ta1"} {
+				if _, _, err := db.AssignNextRequest(assistantID, "1", ""); err != nil {
+					t.Fatalf("AssignNextRequest(%s) failed: %v", assistantID, err)
+				}
+			}
+
+			req := &models.HelpRequest{StudentUserID: "new", GuildID: "1", Type: "help"}
+			if err := db.CreateHelpRequest(req); err != nil {
+				t.Fatalf("CreateHelpRequest failed: %v", err)
+			}
+			if req.AssistantUserID != test.want {
+				t.Errorf("CreateHelpRequest assigned %q, want %q", req.AssistantUserID, test.want)
+			}
+		})
+	}
+}
+
+func TestAssistantStats(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	for _, studentID := range []string{"1", "2", "3", "4"} {
+		if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: studentID, GuildID: "1", Type: "help"}); err != nil {
+			t.Fatalf("CreateHelpRequest failed: %v", err)
+		}
+	}
+	for range 2 {
+		if _, _, err := db.AssignNextRequest("ta1", "1", ""); err != nil {
+			t.Fatalf("AssignNextRequest failed: %v", err)
+		}
+	}
+	if _, err := db.CloseSession("ta1", "1", models.StatusNoShow); err != nil {
+		t.Fatalf("CloseSession failed: %v", err)
+	}
+	if _, _, err := db.AssignNextRequest("ta2", "1", ""); err != nil {
+		t.Fatalf("AssignNextRequest failed: %v", err)
+	}
+	if _, err := db.ClearHelpRequests("ta2", "1"); err != nil {
+		t.Fatalf("ClearHelpRequests failed: %v", err)
+	}
+	// ta3's session is stored with a UTC offset that sorts before the other times as text
+	otherOffset := time.FixedZone("", -12*60*60)
+	now := time.Now().In(otherOffset)
+	session := &models.HelpRequest{
+		StudentUserID: "5", GuildID: "1", Type: "help", AssistantUserID: "ta3", Status: models.StatusResolved, Done: true,
+		AssignedAt: now.Add(-20 * time.Minute), DoneAt: now.Add(-10 * time.Minute),
+	}
+	if err := db.CreateHelpRequest(session); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+
+	stats, err := db.GetAssistantStats("1", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetAssistantStats failed: %v", err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("GetAssistantStats() = %d assistants, want 3", len(stats))
+	}
+	if s := stats[0]; s.UserID != "ta1" || s.Handled != 2 || s.NoShows != 1 || s.Clears != 0 {
+		t.Errorf("GetAssistantStats()[0] = %+v, want ta1 with 2 handled, 1 no-show", s)
+	}
+	if s := stats[1]; s.UserID != "ta3" || s.Handled != 1 || s.AverageSession != 10*time.Minute {
+		t.Errorf("GetAssistantStats()[1] = %+v, want ta3 with 1 handled", s)
+	}
+	// ta2's session is still in progress
+	if s := stats[2]; s.UserID != "ta2" || s.Handled != 0 || s.Clears != 1 || s.Cleared != 1 {
+		t.Errorf("GetAssistantStats()[2] = %+v, want ta2 with 1 clear", s)
+	}
+
+	if stats, err := db.GetAssistantStats("1", time.Now().Add(time.Hour)); err != nil || len(stats) != 0 {
+		t.Errorf("GetAssistantStats() = %v, %v, want none", stats, err)
+	}
+}
+
+func setupTestDatabase(t *testing.T) *Database {
+	db, err := OpenDatabase("file::memory:?cache=shared", logrus.New())
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
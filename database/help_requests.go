@@ -3,70 +3,331 @@ package database
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/Raytar/helpbot/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func (db *Database) ClearHelpRequests(assistantID, guildID string) error {
-	return db.conn.Model(&models.HelpRequest{}).Where("done = ? AND guild_id = ?", false, guildID).Updates(map[string]any{
-		"done":              true,
-		"done_at":           time.Now(),
-		"assistant_user_id": assistantID,
-		"reason":            "assistantClear",
-	}).Error
+// ClearHelpRequests cancels all waiting requests in the guild, and returns the cancelled requests.
+func (db *Database) ClearHelpRequests(assistantID, guildID string) ([]*models.HelpRequest, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("done = ? AND status = ? AND guild_id = ?", false, models.StatusWaiting, guildID).Find(&requests).Error; err != nil {
+			db.log.Errorln("Failed to get waiting requests from DB:", err)
+			return err
+		}
+		if len(requests) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		ids := make([]uint, len(requests))
+		for i, req := range requests {
+			ids[i] = req.ID
+			req.Done = true
+			req.DoneAt = now
+			req.Status = models.StatusCancelled
+			req.AssistantUserID = assistantID
+			req.Reason = "assistantClear"
+		}
+		return tx.Model(&models.HelpRequest{}).Where("id IN ?", ids).Updates(map[string]any{
+			"done":              true,
+			"done_at":           now,
+			"status":            models.StatusCancelled,
+			"assistant_user_id": assistantID,
+			"reason":            "assistantClear",
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
 }
 
-// GetWaitingRequests returns the oldest num requests that are not done. If num is 0, it returns all waiting requests.
+// GetWaitingRequests returns the first num requests that are waiting, in the order they will be assigned
+// according to the guild's lanes. If num is 0, it returns all waiting requests.
 //
 //	db.GetWaitingRequests(0) // returns all waiting requests
-//	db.GetWaitingRequests(5) // returns the 5 oldest waiting requests
-func (db *Database) GetWaitingRequests(guildID string, num int) (requests []*models.HelpRequest, err error) {
-	query := db.conn.Where("done = ? AND guild_id = ?", false, guildID).Order("created_at asc")
-	if num > 0 {
-		query = query.Limit(num)
-	}
-	err = query.Find(&requests).Error
+//	db.GetWaitingRequests(5) // returns the next 5 waiting requests
+func (db *Database) GetWaitingRequests(guildID string, num int) ([]*models.HelpRequest, error) {
+	requests, err := db.waitingRequests(db.conn, guildID, "")
 	if err != nil {
-		db.log.Errorln("Failed to get waiting requests from DB:", err)
+		return nil, err
 	}
-	return
+	if num > 0 && len(requests) > num {
+		requests = requests[:num]
+	}
+	return requests, nil
 }
 
+// hasStudent returns a condition that matches the requests made by the student, or on behalf of a group
+// that the student is a member of.
+func hasStudent(studentID string) clause.Expr {
+	// GroupMemberIDs is stored as a JSON array of strings
+	return gorm.Expr("(student_user_id = ? OR group_member_ids LIKE ?)", studentID, fmt.Sprintf("%%%q%%", studentID))
+}
+
+// CancelHelpRequest cancels the student's waiting request. Requests that are already in progress cannot be cancelled.
 func (db *Database) CancelHelpRequest(guildID, studentID string) error {
-	if err := db.conn.Model(&models.HelpRequest{}).Where("student_user_id = ? and guild_id = ?", studentID, guildID).Updates(map[string]interface{}{
+	result := db.conn.Model(&models.HelpRequest{}).Where(hasStudent(studentID)).Where("guild_id = ? AND done = ? AND status = ?", guildID, false, models.StatusWaiting).Updates(map[string]any{
 		"done":    true,
+		"status":  models.StatusCancelled,
 		"reason":  "userCancel",
 		"done_at": time.Now(),
-	}).Error; err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return fmt.Errorf("you do not have an active help request")
-	} else if err != nil {
+	})
+	if result.Error != nil {
+		db.log.Errorln("Failed to cancel help request:", result.Error)
 		return fmt.Errorf("an unknown error occurred when attempting to cancel your help request")
 	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("you do not have a help request waiting in the queue")
+	}
 	db.log.Infoln("Canceled help request for", studentID)
 	return nil
 }
 
+// UpdateDescription updates the description of the student's waiting request.
+func (db *Database) UpdateDescription(guildID, studentID, description, codeLocation string) error {
+	result := db.conn.Model(&models.HelpRequest{}).Where(hasStudent(studentID)).Where("guild_id = ? AND done = ? AND status = ?", guildID, false, models.StatusWaiting).Updates(map[string]any{
+		"description":   description,
+		"code_location": codeLocation,
+	})
+	if result.Error != nil {
+		db.log.Errorln("Failed to update description:", result.Error)
+		return fmt.Errorf("an unknown error occurred")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("you do not have a help request waiting in the queue")
+	}
+	return nil
+}
+
+// CreateHelpRequest adds the request to the queue. If teaching assistants in the guild are waiting
+// for a new request, the request is assigned to one of them according to the guild's assignment strategy,
+// and request.AssistantUserID is set accordingly.
 func (db *Database) CreateHelpRequest(request *models.HelpRequest) error {
-	// Check if the user already has a help request
-	var exists int64
-	if err := db.conn.Model(&models.HelpRequest{}).Where("student_user_id = ? AND guild_id = ? AND done = ?", request.StudentUserID, request.GuildID, false).Count(&exists).Error; err != nil {
-		db.log.Errorln("Failed to check if user has existing help request:", err)
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		// Check if the user, or a member of the user's group, already has a help request
+		var exists int64
+		if err := tx.Model(&models.HelpRequest{}).Where(hasStudent(request.StudentUserID)).Where("guild_id = ? AND done = ?", request.GuildID, false).Count(&exists).Error; err != nil {
+			db.log.Errorln("Failed to check if user has existing help request:", err)
+			return err
+		}
+
+		if exists > 0 {
+			return fmt.Errorf("you already have an active help request")
+		}
+
+		for _, memberID := range request.GroupMemberIDs {
+			if err := tx.Model(&models.HelpRequest{}).Where(hasStudent(memberID)).Where("guild_id = ? AND done = ?", request.GuildID, false).Count(&exists).Error; err != nil {
+				db.log.Errorln("Failed to check if group member has existing help request:", err)
+				return err
+			}
+
+			if exists > 0 {
+				return fmt.Errorf("a member of your group already has an active help request")
+			}
+		}
+
+		if request.GroupID != 0 {
+			// Check if the group already has a help request
+			if err := tx.Model(&models.HelpRequest{}).Where("group_id = ? AND guild_id = ? AND done = ?", request.GroupID, request.GuildID, false).Count(&exists).Error; err != nil {
+				db.log.Errorln("Failed to check if group has existing help request:", err)
+				return err
+			}
+
+			if exists > 0 {
+				return fmt.Errorf("your group already has an active help request")
+			}
+		}
+
+		if err := tx.Create(request).Error; err != nil {
+			db.log.Errorln("Failed to create help request:", err)
+			return err
+		}
+
+		if request.Done {
+			return nil
+		}
+
+		assistant, err := db.pickAssistant(tx, request.GuildID, request.Type)
+		if err != nil || assistant == nil {
+			return err
+		}
+		return db.assign(tx, request, assistant)
+	})
+}
+
+// assign marks the waiting request as in progress with the assistant, and removes the assistant's waiting status.
+// It fails if the request is no longer waiting, e.g., because another assistant took it.
+func (db *Database) assign(tx *gorm.DB, request *models.HelpRequest, assistant *models.Assistant) error {
+	now := time.Now()
+	result := tx.Model(&models.HelpRequest{}).Where("id = ? AND done = ? AND status = ?", request.ID, false, models.StatusWaiting).Updates(map[string]any{
+		"assistant_user_id": assistant.UserID,
+		"status":            models.StatusInProgress,
+		"assigned_at":       now,
+	})
+	if result.Error != nil {
+		db.log.Errorln("Failed to update help request:", result.Error)
+		return fmt.Errorf("an error occurred while assigning the request")
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("the request is no longer waiting in the queue, it may have been taken by another teaching assistant")
+	}
+
+	err := tx.Model(assistant).Updates(map[string]any{
+		"waiting":      false,
+		"last_request": now,
+	}).Error
+	if err != nil {
+		db.log.Errorln("Failed to update assistant:", err)
 		return err
 	}
 
-	if exists > 0 {
-		return fmt.Errorf("you already have an active help request")
+	assistant.Waiting = false
+	assistant.LastRequest = now
+	request.AssistantUserID = assistant.UserID
+	request.Assistant = *assistant
+	request.Status = models.StatusInProgress
+	request.AssignedAt = now
+	return nil
+}
+
+// closeSession closes the assistant's session that is in progress, if any.
+func (db *Database) closeSession(tx *gorm.DB, assistantID, guildID string, status models.RequestStatus, reason string) (*models.HelpRequest, error) {
+	var requests []*models.HelpRequest
+	err := tx.Where("assistant_user_id = ? AND guild_id = ? AND done = ? AND status = ?", assistantID, guildID, false, models.StatusInProgress).Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get sessions in progress from DB:", err)
+		return nil, fmt.Errorf("an error occurred while fetching your current session")
+	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	ids := make([]uint, len(requests))
+	for i, req := range requests {
+		ids[i] = req.ID
+		req.Done = true
+		req.DoneAt = now
+		req.Status = status
+		req.Reason = reason
+	}
+	err = tx.Model(&models.HelpRequest{}).Where("id IN ?", ids).Updates(map[string]any{
+		"done":    true,
+		"done_at": now,
+		"status":  status,
+		"reason":  reason,
+	}).Error
+	if err != nil {
+		db.log.Errorln("Failed to close session:", err)
+		return nil, fmt.Errorf("an error occurred while closing your current session")
 	}
+	return requests[0], nil
+}
 
-	if err := db.conn.Create(request).Error; err != nil {
-		db.log.Errorln("Failed to create help request:", err)
+// CloseSession closes the assistant's session that is in progress with the given outcome.
+// It returns nil if the assistant has no session in progress.
+func (db *Database) CloseSession(assistantID, guildID string, status models.RequestStatus) (*models.HelpRequest, error) {
+	var req *models.HelpRequest
+	err := db.conn.Transaction(func(tx *gorm.DB) (err error) {
+		req, err = db.closeSession(tx, assistantID, guildID, status, "assistantDone")
 		return err
+	})
+	return req, err
+}
+
+// GetSession returns the assistant's session that is in progress, or nil if the assistant has no session in progress.
+func (db *Database) GetSession(assistantID, guildID string) (*models.HelpRequest, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Where("assistant_user_id = ? AND guild_id = ? AND done = ? AND status = ?", assistantID, guildID, false, models.StatusInProgress).Limit(1).Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get session in progress from DB:", err)
+		return nil, err
 	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return requests[0], nil
+}
+
+// GetSessions returns the sessions in progress in the guild, ordered by when they started.
+func (db *Database) GetSessions(guildID string) (requests []*models.HelpRequest, err error) {
+	err = db.conn.Where("guild_id = ? AND done = ? AND status = ?", guildID, false, models.StatusInProgress).Order("assigned_at asc").Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get sessions in progress from DB:", err)
+	}
+	return
+}
+
+// SetSubmissionStatus records the QuickFeed submission status that was set during the request's session,
+// both as the request's latest status and as a grade by the request's assistant.
+func (db *Database) SetSubmissionStatus(request *models.HelpRequest, submissionID uint64, status string) error {
+	now := time.Now()
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.HelpRequest{}).Where("id = ?", request.ID).Updates(map[string]any{
+			"submission_id":         submissionID,
+			"submission_status":     status,
+			"submission_updated_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&models.Grade{
+			GuildID:         request.GuildID,
+			HelpRequestID:   request.ID,
+			AssistantUserID: request.AssistantUserID,
+			SubmissionID:    submissionID,
+			Status:          status,
+		}).Error
+	})
+	if err != nil {
+		db.log.Errorln("Failed to update submission status of help request:", err)
+		return err
+	}
+	request.SubmissionID = submissionID
+	request.SubmissionStatus = status
+	request.SubmissionUpdatedAt = now
+	return nil
+}
+
+// GetGrades returns the submission statuses set during the request's session, oldest first.
+func (db *Database) GetGrades(requestID uint) (grades []*models.Grade, err error) {
+	if err = db.conn.Where("help_request_id = ?", requestID).Order("id asc").Find(&grades).Error; err != nil {
+		db.log.Errorln("Failed to get grades from DB:", err)
+	}
+	return
+}
+
+// SetThreadID records the private thread of the request's session.
+func (db *Database) SetThreadID(request *models.HelpRequest, threadID string) error {
+	if err := db.conn.Model(&models.HelpRequest{}).Where("id = ?", request.ID).Update("thread_id", threadID).Error; err != nil {
+		db.log.Errorln("Failed to update thread of help request:", err)
+		return err
+	}
+	request.ThreadID = threadID
 	return nil
 }
 
+// GetActiveRequest returns the student's request that is either waiting or in progress.
+// It returns nil if the student has no active request.
+func (db *Database) GetActiveRequest(guildID, studentID string) (*models.HelpRequest, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Where(hasStudent(studentID)).Where("guild_id = ? AND done = ?", guildID, false).Order("created_at desc").Limit(1).Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get active request from DB:", err)
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return requests[0], nil
+}
+
 func (db *Database) GetHelpRequest(request *models.HelpRequest) (r *models.HelpRequest, err error) {
 	if err := db.conn.Model(request).Where("student_user_id = ? AND guild_id = ?", request.StudentUserID, request.GuildID).First(&request).Error; err != nil {
 		db.log.Errorln("Failed to get help request from DB:", err)
@@ -75,82 +336,129 @@ func (db *Database) GetHelpRequest(request *models.HelpRequest) (r *models.HelpR
 	return request, nil
 }
 
-func (db *Database) GetQueuePosition(guildID, userID string) (rowNumber int, err error) {
-	rows, err := db.conn.Model(&models.HelpRequest{}).Select("student_user_id").Where("done = ? AND guild_id = ?", false, guildID).Order("created_at asc").Rows()
-	defer rows.Close()
+// GetQueuePosition returns the position in the queue of the request made by the user, or on behalf of the user's group,
+// in the order the requests will be assigned according to the guild's lanes. It returns 0 if the user has no waiting request.
+func (db *Database) GetQueuePosition(guildID, userID string) (int, error) {
+	requests, err := db.waitingRequests(db.conn, guildID, "")
 	if err != nil {
 		return -1, fmt.Errorf("getPosInQueue error: %w", err)
 	}
 
-	found := false
-	for rows.Next() {
-		rowNumber++
-		var studentUserID string
-		if err := rows.Scan(&studentUserID); err != nil {
-			return -1, fmt.Errorf("getPosInQueue error: %w", err)
+	for i, req := range requests {
+		if req.StudentUserID == userID || slices.Contains(req.GroupMemberIDs, userID) {
+			return i + 1, nil
 		}
-		if studentUserID == userID {
-			found = true
-			break
-		}
-	}
-	if !found {
-		return 0, nil
 	}
-	return
+	return 0, nil
 }
 
-func (db *Database) AssignNextRequest(assistantID, guildID string) (*models.HelpRequest, error) {
-	var req *models.HelpRequest
-	err := db.conn.Transaction(func(tx *gorm.DB) error {
+// AssignNextRequest assigns the next waiting request to the assistant, according to the guild's lanes.
+// If lane is not empty, only requests in that lane are considered. The assistant's current session,
+// if any, is closed as resolved. If there are no waiting requests, the assistant is marked as waiting,
+// and nil is returned. A waiting assistant is assigned the next request that is created in the lane.
+// The closed session is returned as well, or nil if the assistant had no session in progress.
+func (db *Database) AssignNextRequest(assistantID, guildID, lane string) (*models.HelpRequest, *models.HelpRequest, error) {
+	var req, closed *models.HelpRequest
+	err := db.conn.Transaction(func(tx *gorm.DB) (err error) {
 		assistant := &models.Assistant{UserID: assistantID, GuildID: guildID}
 		if err := tx.Model(assistant).Where("user_id = ? AND guild_id = ?", assistant.UserID, assistant.GuildID).FirstOrCreate(assistant).Error; err != nil {
 			db.log.Errorln("Failed to get assistant from DB:", err)
 			return err
 		}
 
-		var requests []*models.HelpRequest
-		// Get the oldest waiting request
-		err := tx.Where("done = ? AND guild_id = ?", false, guildID).Order("created_at asc").Limit(1).Find(&requests).Error
-		if err != nil {
-			db.log.Errorln("Failed to get waiting requests from DB:", err)
+		if closed, err = db.closeSession(tx, assistantID, guildID, models.StatusResolved, "assistantNext"); err != nil {
 			return err
 		}
 
-		if err == gorm.ErrRecordNotFound {
-			assistant.Waiting = true
-			err := tx.Model(&models.Assistant{}).Save(&assistant).Error
+		next, err := db.nextRequest(tx, guildID, lane)
+		if err != nil {
+			return fmt.Errorf("an error occurred while fetching the next request")
+		}
+
+		if next == nil {
+			updates := map[string]any{
+				"waiting":       true,
+				"waiting_since": time.Now(),
+				"waiting_lane":  lane,
+			}
+			if assistant.Waiting {
+				// Keep the assistant's place among the waiting assistants.
+				delete(updates, "waiting_since")
+			}
+			err := tx.Model(assistant).Updates(updates).Error
 			if err != nil {
+				db.log.Errorln("Failed to update assistant:", err)
 				return fmt.Errorf("there are no more requests in the queue, but due to an error, you won't receive a notification when the next one arrives")
 			}
-		} else if err != nil {
-			return fmt.Errorf("an error occurred while fetching the next request")
+			return nil
 		}
 
-		if len(requests) == 0 {
-			return fmt.Errorf("there are no more requests in the queue")
+		if err := db.assign(tx, next, assistant); err != nil {
+			return err
 		}
+		req = next
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return req, closed, nil
+}
 
-		request := requests[0]
-		request.Assistant = *assistant
-		request.Assistant.LastRequest = time.Now()
-		err = tx.Model(&models.HelpRequest{}).Where("id = ?", request.ID).Updates(map[string]interface{}{
-			"assistant_user_id": assistantID,
-			"done":              true,
-			"done_at":           time.Now(),
-			"reason":            "assistantNext",
-		}).Error
-		if err != nil {
-			db.log.Errorln("Failed to update help request:", err)
-			return fmt.Errorf("an error occurred while fetching the next request")
+// AssignRequest assigns the waiting request with the given ID to the assistant. The assistant's current session,
+// if any, is closed as resolved, and returned as well. It fails without closing the current session if the request is not waiting.
+func (db *Database) AssignRequest(assistantID, guildID string, requestID uint) (*models.HelpRequest, *models.HelpRequest, error) {
+	var req models.HelpRequest
+	var closed *models.HelpRequest
+	err := db.conn.Transaction(func(tx *gorm.DB) (err error) {
+		assistant := &models.Assistant{UserID: assistantID, GuildID: guildID}
+		if err := tx.Model(assistant).Where("user_id = ? AND guild_id = ?", assistant.UserID, assistant.GuildID).FirstOrCreate(assistant).Error; err != nil {
+			db.log.Errorln("Failed to get assistant from DB:", err)
+			return err
+		}
+
+		if err := tx.Where("id = ? AND guild_id = ?", requestID, guildID).First(&req).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("the request does not exist")
+			}
+			db.log.Errorln("Failed to get help request from DB:", err)
+			return fmt.Errorf("an error occurred while fetching the request")
 		}
 
-		if err := tx.Model(assistant).Update("last_request", time.Now()).Error; err != nil {
-			db.log.Errorln("Failed to update assistant:", err)
+		if closed, err = db.closeSession(tx, assistantID, guildID, models.StatusResolved, "assistantNext"); err != nil {
 			return err
 		}
-		req = request
-		return nil
+		// Rolls back the closed session if the request was taken in the meantime
+		return db.assign(tx, &req, assistant)
 	})
-	return req, err
+	if err != nil {
+		return nil, nil, err
+	}
+	return &req, closed, nil
+}
+
+// GetHelpRequests returns the requests in the guild that were created in the given range, ordered by when they were created.
+// A zero start or end leaves the range open.
+func (db *Database) GetHelpRequests(guildID string, start, end time.Time) ([]*models.HelpRequest, error) {
+	query := db.conn.Where("guild_id = ?", guildID)
+	if !start.IsZero() {
+		query = query.Where("created_at >= ?", start.Add(-offsetMargin))
+	}
+	if !end.IsZero() {
+		query = query.Where("created_at < ?", end.Add(offsetMargin))
+	}
+	var requests []*models.HelpRequest
+	if err := query.Find(&requests).Error; err != nil {
+		db.log.Errorln("Failed to get help requests from DB:", err)
+		return nil, err
+	}
+
+	inRange := requests[:0]
+	for _, req := range requests {
+		if (start.IsZero() || !req.CreatedAt.Before(start)) && (end.IsZero() || req.CreatedAt.Before(end)) {
+			inRange = append(inRange, req)
+		}
+	}
+	slices.SortStableFunc(inRange, func(a, b *models.HelpRequest) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return inRange, nil
 }
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"gorm.io/gorm"
+)
+
+// GetLanes returns the lanes configured for the guild, ordered by priority.
+func (db *Database) GetLanes(guildID string) (lanes []*models.Lane, err error) {
+	if err = db.conn.Where("guild_id = ?", guildID).Order("priority desc, name asc").Find(&lanes).Error; err != nil {
+		db.log.Errorln("Failed to get lanes from DB:", err)
+	}
+	return
+}
+
+// SetLane creates the lane, or updates the priority and weight of the guild's lane with the same name.
+func (db *Database) SetLane(lane *models.Lane) error {
+	if lane.Weight < 1 {
+		return fmt.Errorf("the weight must be at least 1")
+	}
+	err := db.conn.Where("guild_id = ? AND name = ?", lane.GuildID, lane.Name).
+		Assign(map[string]any{"priority": lane.Priority, "weight": lane.Weight}).
+		FirstOrCreate(lane).Error
+	if err != nil {
+		db.log.Errorln("Failed to save lane:", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteLane deletes the guild's lane with the given name. Its requests get the default priority and weight.
+func (db *Database) DeleteLane(guildID, name string) error {
+	result := db.conn.Unscoped().Where("guild_id = ? AND name = ?", guildID, name).Delete(&models.Lane{})
+	if result.Error != nil {
+		db.log.Errorln("Failed to delete lane:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("there is no lane named %s", name)
+	}
+	return nil
+}
+
+// CountWaitingByLane returns the number of waiting requests in the guild. key is the lane, i.e., the request type.
+func (db *Database) CountWaitingByLane(guildID string) (map[string]int, error) {
+	var rows []struct {
+		Type  string
+		Count int
+	}
+	err := db.conn.Model(&models.HelpRequest{}).Select("type, count(*) as count").
+		Where("done = ? AND status = ? AND guild_id = ?", false, models.StatusWaiting, guildID).
+		Group("type").Scan(&rows).Error
+	if err != nil {
+		db.log.Errorln("Failed to count waiting requests:", err)
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// nextRequest returns the waiting request in the guild that should be assigned next according to the guild's lanes,
+// or nil if there are no waiting requests. If lane is not empty, only requests in that lane are considered.
+func (db *Database) nextRequest(tx *gorm.DB, guildID, lane string) (*models.HelpRequest, error) {
+	requests, err := db.waitingRequests(tx, guildID, lane)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return requests[0], nil
+}
+
+// waitingRequests returns the waiting requests in the guild in the order they will be assigned according to
+// the guild's lanes. If lane is not empty, only requests in that lane are returned.
+func (db *Database) waitingRequests(tx *gorm.DB, guildID, lane string) ([]*models.HelpRequest, error) {
+	var lanes []*models.Lane
+	if err := tx.Where("guild_id = ?", guildID).Find(&lanes).Error; err != nil {
+		db.log.Errorln("Failed to get lanes from DB:", err)
+		return nil, err
+	}
+
+	query := tx.Where("done = ? AND status = ? AND guild_id = ?", false, models.StatusWaiting, guildID)
+	if lane != "" {
+		query = query.Where("type = ?", lane)
+	}
+	var requests []*models.HelpRequest
+	if err := query.Find(&requests).Error; err != nil {
+		db.log.Errorln("Failed to get waiting requests from DB:", err)
+		return nil, err
+	}
+	slices.SortStableFunc(requests, func(a, b *models.HelpRequest) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return inLaneOrder(requests, lanes, time.Now()), nil
+}
+
+// inLaneOrder orders the requests, given in the order they were made, by taking the request with the
+// highest priority and weighted age from the front of the lanes until all requests are taken.
+// The weighted ages are computed at the given time, so the order may change as time passes.
+func inLaneOrder(requests []*models.HelpRequest, lanes []*models.Lane, now time.Time) []*models.HelpRequest {
+	config := make(map[string]*models.Lane)
+	for _, l := range lanes {
+		config[l.Name] = l
+	}
+	// Each lane is a queue of requests in the order they were made
+	queues := make(map[string][]*models.HelpRequest)
+	var types []string
+	for _, req := range requests {
+		if _, ok := queues[req.Type]; !ok {
+			types = append(types, req.Type)
+		}
+		queues[req.Type] = append(queues[req.Type], req)
+	}
+
+	ordered := make([]*models.HelpRequest, 0, len(requests))
+	for len(ordered) < len(requests) {
+		var next *models.HelpRequest
+		var nextPriority int
+		var nextScore float64
+		for _, t := range types {
+			if len(queues[t]) == 0 {
+				continue
+			}
+			// The oldest request in each lane has the highest weighted age in the lane
+			req := queues[t][0]
+			priority, weight := 0, 1
+			if l, ok := config[t]; ok {
+				priority, weight = l.Priority, l.Weight
+			}
+			score := now.Sub(req.CreatedAt).Seconds() * float64(weight)
+			if next == nil || priority > nextPriority || (priority == nextPriority && score > nextScore) {
+				next, nextPriority, nextScore = req, priority, score
+			}
+		}
+		ordered = append(ordered, next)
+		queues[next.Type] = queues[next.Type][1:]
+	}
+	return ordered
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/Raytar/helpbot/models"
+)
+
+// SetQueueState sets the queue state of the course configured for the guild.
+func (db *Database) SetQueueState(guildID string, state models.QueueState) error {
+	result := db.conn.Model(&models.Course{}).Where("guild_id = ?", guildID).Update("queue_state", state)
+	if result.Error != nil {
+		db.log.Errorln("Failed to update queue state:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("this server is not configured with a course")
+	}
+	return nil
+}
+
+// GetLabHours returns the lab hours of the guild, ordered by day and start time.
+func (db *Database) GetLabHours(guildID string) (hours []*models.LabHours, err error) {
+	if err = db.conn.Where("guild_id = ?", guildID).Order("weekday asc, start asc").Find(&hours).Error; err != nil {
+		db.log.Errorln("Failed to get lab hours from DB:", err)
+	}
+	return
+}
+
+func (db *Database) CreateLabHours(hours *models.LabHours) error {
+	if err := db.conn.Create(hours).Error; err != nil {
+		db.log.Errorln("Failed to create lab hours:", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteLabHours deletes the guild's lab hours with the given ID.
+func (db *Database) DeleteLabHours(guildID string, id uint) error {
+	result := db.conn.Where("guild_id = ?", guildID).Delete(&models.LabHours{}, id)
+	if result.Error != nil {
+		db.log.Errorln("Failed to delete lab hours:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no lab hours with id %d", id)
+	}
+	return nil
+}
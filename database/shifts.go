@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"gorm.io/gorm"
+)
+
+// StartShift records that the assistant is on duty in the guild from now on.
+func (db *Database) StartShift(guildID, userID string) (*models.Shift, error) {
+	shift := &models.Shift{GuildID: guildID, UserID: userID, OnDuty: true, StartedAt: time.Now()}
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		var onDuty int64
+		if err := tx.Model(&models.Shift{}).Where("guild_id = ? AND user_id = ? AND on_duty = ?", guildID, userID, true).Count(&onDuty).Error; err != nil {
+			db.log.Errorln("Failed to check if assistant is on duty:", err)
+			return fmt.Errorf("an error occurred while fetching your shift")
+		}
+		if onDuty > 0 {
+			return fmt.Errorf("you are already on duty")
+		}
+		if err := tx.Create(shift).Error; err != nil {
+			db.log.Errorln("Failed to create shift:", err)
+			return fmt.Errorf("an error occurred while starting your shift")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// EndShift records that the assistant is no longer on duty in the guild, and removes the assistant's waiting status.
+func (db *Database) EndShift(guildID, userID string) (*models.Shift, error) {
+	var shifts []*models.Shift
+	err := db.conn.Transaction(func(tx *gorm.DB) (err error) {
+		shifts, err = db.endShifts(tx, guildID, []string{userID}, "offDuty")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(shifts) == 0 {
+		return nil, fmt.Errorf("you are not on duty")
+	}
+	return shifts[0], nil
+}
+
+// EndShiftsStartedBefore signs off the assistants in the guild, or in all guilds if guildID is empty,
+// whose shifts started before t, and returns the ended shifts.
+func (db *Database) EndShiftsStartedBefore(guildID string, t time.Time, reason string) ([]*models.Shift, error) {
+	var ended []*models.Shift
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("on_duty = ? AND started_at < ?", true, t.Add(offsetMargin))
+		if guildID != "" {
+			query = query.Where("guild_id = ?", guildID)
+		}
+		var shifts []*models.Shift
+		if err := query.Find(&shifts).Error; err != nil {
+			db.log.Errorln("Failed to get shifts from DB:", err)
+			return fmt.Errorf("an error occurred while fetching the shifts")
+		}
+		assistants := make(map[string][]string)
+		for _, shift := range shifts {
+			if shift.StartedAt.Before(t) {
+				assistants[shift.GuildID] = append(assistants[shift.GuildID], shift.UserID)
+			}
+		}
+		for guildID, userIDs := range assistants {
+			guildShifts, err := db.endShifts(tx, guildID, userIDs, reason)
+			if err != nil {
+				return err
+			}
+			ended = append(ended, guildShifts...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ended, nil
+}
+
+// endShifts ends the shifts of the given assistants in the guild, or of all assistants if userIDs is nil.
+func (db *Database) endShifts(tx *gorm.DB, guildID string, userIDs []string, reason string) ([]*models.Shift, error) {
+	query := tx.Where("guild_id = ? AND on_duty = ?", guildID, true)
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	var shifts []*models.Shift
+	if err := query.Find(&shifts).Error; err != nil {
+		db.log.Errorln("Failed to get shifts from DB:", err)
+		return nil, fmt.Errorf("an error occurred while fetching the shifts")
+	}
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	ids := make([]uint, len(shifts))
+	assistants := make([]string, len(shifts))
+	for i, shift := range shifts {
+		ids[i] = shift.ID
+		assistants[i] = shift.UserID
+		shift.OnDuty = false
+		shift.EndedAt = now
+		shift.Reason = reason
+	}
+	if err := tx.Model(&models.Shift{}).Where("id IN ?", ids).Updates(map[string]any{"on_duty": false, "ended_at": now, "reason": reason}).Error; err != nil {
+		db.log.Errorln("Failed to end shifts:", err)
+		return nil, fmt.Errorf("an error occurred while ending the shifts")
+	}
+	// Assistants that are off duty should not be assigned new requests
+	if err := tx.Model(&models.Assistant{}).Where("guild_id = ? AND user_id IN ?", guildID, assistants).Update("waiting", false).Error; err != nil {
+		db.log.Errorln("Failed to update assistants:", err)
+		return nil, fmt.Errorf("an error occurred while ending the shifts")
+	}
+	return shifts, nil
+}
+
+// GetOnDuty returns the shifts of the assistants that are on duty in the guild, ordered by when they started.
+func (db *Database) GetOnDuty(guildID string) (shifts []*models.Shift, err error) {
+	if err = db.conn.Where("guild_id = ? AND on_duty = ?", guildID, true).Order("started_at asc").Find(&shifts).Error; err != nil {
+		db.log.Errorln("Failed to get shifts from DB:", err)
+	}
+	return
+}
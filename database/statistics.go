@@ -0,0 +1,142 @@
+package database
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+)
+
+// GetAverageSessionDurations returns the average duration of the sessions in the guild for each request type,
+// based on the sessions that were closed after the given time.
+func (db *Database) GetAverageSessionDurations(guildID string, since time.Time) (map[string]time.Duration, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Select("type", "assigned_at", "done_at").
+		Where("guild_id = ? AND status IN ? AND done_at > ?", guildID, []models.RequestStatus{models.StatusResolved, models.StatusNoShow}, since.Add(-offsetMargin)).
+		Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get sessions from DB:", err)
+		return nil, err
+	}
+
+	total := make(map[string]time.Duration)
+	count := make(map[string]int)
+	for _, req := range requests {
+		if !req.DoneAt.After(since) || req.AssignedAt.IsZero() || req.DoneAt.Before(req.AssignedAt) {
+			continue
+		}
+		total[req.Type] += req.DoneAt.Sub(req.AssignedAt)
+		count[req.Type]++
+	}
+	averages := make(map[string]time.Duration)
+	for requestType, n := range count {
+		averages[requestType] = total[requestType] / time.Duration(n)
+	}
+	return averages, nil
+}
+
+// CountActiveAssistants returns the number of assistants in the guild that are waiting for a request,
+// have a session in progress, or have been assigned a request after the given time.
+func (db *Database) CountActiveAssistants(guildID string, since time.Time) (int, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Select("assistant_user_id", "status", "assigned_at").
+		Where("guild_id = ? AND assistant_user_id <> '' AND (status = ? OR assigned_at > ?)", guildID, models.StatusInProgress, since.Add(-offsetMargin)).
+		Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get active assistants from DB:", err)
+		return 0, err
+	}
+	var waiting []string
+	err = db.conn.Model(&models.Assistant{}).Where("guild_id = ? AND waiting = ?", guildID, true).Pluck("user_id", &waiting).Error
+	if err != nil {
+		db.log.Errorln("Failed to get waiting assistants from DB:", err)
+		return 0, err
+	}
+
+	active := make(map[string]bool)
+	for _, req := range requests {
+		if req.Status == models.StatusInProgress || req.AssignedAt.After(since) {
+			active[req.AssistantUserID] = true
+		}
+	}
+	for _, id := range waiting {
+		active[id] = true
+	}
+	return len(active), nil
+}
+
+// AssistantStats summarizes the work of an assistant in a guild.
+type AssistantStats struct {
+	UserID string
+	// Handled is the number of sessions the assistant closed, of which NoShows were closed as no-show.
+	Handled int
+	NoShows int
+	// AverageSession is the average duration of the handled sessions.
+	AverageSession time.Duration
+	// AverageWait is the average time the students in the handled sessions waited before they were assigned to the assistant.
+	AverageWait time.Duration
+	// Clears is the number of times the assistant cleared the queue, removing Cleared requests in total.
+	Clears  int
+	Cleared int
+}
+
+// GetAssistantStats returns the statistics of each assistant in the guild, based on the requests that were
+// closed after the given time. The assistants are ordered by the number of handled sessions.
+func (db *Database) GetAssistantStats(guildID string, since time.Time) ([]*AssistantStats, error) {
+	var requests []*models.HelpRequest
+	err := db.conn.Select("assistant_user_id", "created_at", "assigned_at", "done_at", "status", "reason").
+		Where("guild_id = ? AND assistant_user_id <> '' AND done = ? AND done_at > ?", guildID, true, since.Add(-offsetMargin)).
+		Find(&requests).Error
+	if err != nil {
+		db.log.Errorln("Failed to get closed requests from DB:", err)
+		return nil, err
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].DoneAt.Before(requests[j].DoneAt) })
+
+	stats := make(map[string]*AssistantStats)
+	sessions := make(map[string]time.Duration)
+	waits := make(map[string]time.Duration)
+	// A clear closes all the cleared requests at the same time
+	lastClear := make(map[string]time.Time)
+	for _, req := range requests {
+		if !req.DoneAt.After(since) {
+			continue
+		}
+		s, ok := stats[req.AssistantUserID]
+		if !ok {
+			s = &AssistantStats{UserID: req.AssistantUserID}
+			stats[req.AssistantUserID] = s
+		}
+		switch {
+		case req.Reason == "assistantClear":
+			s.Cleared++
+			if !req.DoneAt.Equal(lastClear[s.UserID]) {
+				s.Clears++
+				lastClear[s.UserID] = req.DoneAt
+			}
+		case req.Status == models.StatusResolved || req.Status == models.StatusNoShow:
+			s.Handled++
+			if req.Status == models.StatusNoShow {
+				s.NoShows++
+			}
+			sessions[s.UserID] += req.DoneAt.Sub(req.AssignedAt)
+			waits[s.UserID] += req.AssignedAt.Sub(req.CreatedAt)
+		}
+	}
+
+	result := make([]*AssistantStats, 0, len(stats))
+	for _, s := range stats {
+		if s.Handled > 0 {
+			s.AverageSession = sessions[s.UserID] / time.Duration(s.Handled)
+			s.AverageWait = waits[s.UserID] / time.Duration(s.Handled)
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Handled != result[j].Handled {
+			return result[i].Handled > result[j].Handled
+		}
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"gorm.io/gorm"
+)
+
+// assignmentStrategy picks which of the waiting assistants is assigned a new request in a guild.
+// The candidates are ordered by how long they have waited, and there is at least one candidate.
+// now is the current time in the timezone of the guild's lab hours.
+type assignmentStrategy interface {
+	pick(tx *gorm.DB, guildID string, candidates []*models.Assistant, now time.Time) (*models.Assistant, error)
+}
+
+var strategies = map[models.AssignmentStrategy]assignmentStrategy{
+	models.StrategyFIFO:        fifo{},
+	models.StrategyRoundRobin:  roundRobin{},
+	models.StrategyLeastLoaded: leastLoaded{},
+}
+
+// fifo picks the assistant that has waited the longest.
+type fifo struct{}
+
+func (fifo) pick(_ *gorm.DB, _ string, candidates []*models.Assistant, _ time.Time) (*models.Assistant, error) {
+	return candidates[0], nil
+}
+
+// roundRobin picks the assistant that was least recently assigned a request,
+// such that the assistants take turns regardless of when they started waiting.
+type roundRobin struct{}
+
+func (roundRobin) pick(tx *gorm.DB, guildID string, candidates []*models.Assistant, _ time.Time) (*models.Assistant, error) {
+	assigned, err := assignedTimes(tx, guildID, candidates, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	lastAssigned := make(map[string]time.Time)
+	for userID, times := range assigned {
+		for _, t := range times {
+			if t.After(lastAssigned[userID]) {
+				lastAssigned[userID] = t
+			}
+		}
+	}
+
+	next := candidates[0]
+	for _, assistant := range candidates[1:] {
+		if lastAssigned[assistant.UserID].Before(lastAssigned[next.UserID]) {
+			next = assistant
+		}
+	}
+	return next, nil
+}
+
+// leastLoaded picks the assistant that has been assigned the fewest requests today.
+type leastLoaded struct{}
+
+func (leastLoaded) pick(tx *gorm.DB, guildID string, candidates []*models.Assistant, now time.Time) (*models.Assistant, error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	assigned, err := assignedTimes(tx, guildID, candidates, today)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make(map[string]int)
+	for userID, times := range assigned {
+		for _, t := range times {
+			if !t.Before(today) {
+				sessions[userID]++
+			}
+		}
+	}
+
+	next := candidates[0]
+	for _, assistant := range candidates[1:] {
+		if sessions[assistant.UserID] < sessions[next.UserID] {
+			next = assistant
+		}
+	}
+	return next, nil
+}
+
+// assignedTimes returns the times the candidates were assigned requests in the guild, keyed by user ID.
+// If since is not zero, the times are at least from since, but may include earlier times.
+func assignedTimes(tx *gorm.DB, guildID string, candidates []*models.Assistant, since time.Time) (map[string][]time.Time, error) {
+	query := tx.Select("assistant_user_id", "assigned_at").Where("guild_id = ? AND assistant_user_id IN ?", guildID, userIDs(candidates))
+	if !since.IsZero() {
+		query = query.Where("assigned_at >= ?", since.Add(-offsetMargin))
+	}
+	var requests []*models.HelpRequest
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	assigned := make(map[string][]time.Time)
+	for _, req := range requests {
+		if !req.AssignedAt.IsZero() {
+			assigned[req.AssistantUserID] = append(assigned[req.AssistantUserID], req.AssignedAt)
+		}
+	}
+	return assigned, nil
+}
+
+// userIDs returns the user IDs of the assistants.
+func userIDs(assistants []*models.Assistant) []string {
+	ids := make([]string, len(assistants))
+	for i, assistant := range assistants {
+		ids[i] = assistant.UserID
+	}
+	return ids
+}
+
+// pickAssistant returns the waiting assistant that should be assigned a new request in the given lane,
+// according to the guild's assignment strategy. If any assistants are on duty in the guild, only they are candidates.
+// It returns nil if no candidate is waiting for the lane.
+func (db *Database) pickAssistant(tx *gorm.DB, guildID, lane string) (*models.Assistant, error) {
+	var candidates []*models.Assistant
+	err := tx.Where("waiting = ? AND guild_id = ? AND waiting_lane IN ?", true, guildID, []string{"", lane}).Order("waiting_since asc").Find(&candidates).Error
+	if err != nil {
+		db.log.Errorln("Failed to get waiting assistants from DB:", err)
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	var onDuty []string
+	if err := tx.Model(&models.Shift{}).Where("guild_id = ? AND on_duty = ?", guildID, true).Pluck("user_id", &onDuty).Error; err != nil {
+		db.log.Errorln("Failed to get shifts from DB:", err)
+		return nil, err
+	}
+	if len(onDuty) > 0 {
+		// When the assistants use shifts, only those on duty are assigned new requests
+		candidates = slices.DeleteFunc(candidates, func(assistant *models.Assistant) bool {
+			return !slices.Contains(onDuty, assistant.UserID)
+		})
+		if len(candidates) == 0 {
+			return nil, nil
+		}
+	}
+
+	var courses []*models.Course
+	if err := tx.Where("guild_id = ?", guildID).Limit(1).Find(&courses).Error; err != nil {
+		db.log.Errorln("Failed to get course from DB:", err)
+		return nil, err
+	}
+	strategy := strategies[models.StrategyFIFO]
+	if len(courses) > 0 {
+		if s, ok := strategies[courses[0].AssignmentStrategy]; ok {
+			strategy = s
+		}
+	}
+	assistant, err := strategy.pick(tx, guildID, candidates, time.Now().In(db.location))
+	if err != nil {
+		db.log.Errorln("Failed to pick waiting assistant:", err)
+		return nil, err
+	}
+	return assistant, nil
+}
+
+// SetAssignmentStrategy sets the strategy used to pick which waiting assistant is assigned a new request in the guild.
+func (db *Database) SetAssignmentStrategy(guildID string, strategy models.AssignmentStrategy) error {
+	if _, ok := strategies[strategy]; !ok {
+		return fmt.Errorf("unknown assignment strategy: %s", strategy)
+	}
+	result := db.conn.Model(&models.Course{}).Where("guild_id = ?", guildID).Update("assignment_strategy", strategy)
+	if result.Error != nil {
+		db.log.Errorln("Failed to update assignment strategy:", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("this server is not configured with a course")
+	}
+	return nil
+}
@@ -27,3 +27,28 @@ func (db *Database) GetStudent(s *models.Student) (student *models.Student, err
 	}
 	return
 }
+
+// GetGuildStudent returns the student with the given Discord user ID in the guild, or nil if the user is not registered.
+func (db *Database) GetGuildStudent(guildID, userID string) (*models.Student, error) {
+	var students []*models.Student
+	if err := db.conn.Where("user_id = ? AND guild_id = ?", userID, guildID).Limit(1).Find(&students).Error; err != nil {
+		db.log.Errorln("Failed to get student from DB:", err)
+		return nil, err
+	}
+	if len(students) == 0 {
+		return nil, nil
+	}
+	return students[0], nil
+}
+
+func (db *Database) UpdateStudent(student *models.Student) error {
+	return db.conn.Save(student).Error
+}
+
+// GetStudentsByLogin returns the students in the guild with the given GitHub logins.
+func (db *Database) GetStudentsByLogin(guildID string, logins []string) (students []*models.Student, err error) {
+	if err = db.conn.Where("guild_id = ? AND github_login IN ?", guildID, logins).Find(&students).Error; err != nil {
+		db.log.Errorln("Failed to get students from DB:", err)
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package helpbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+)
+
+const (
+	// historyWindow is how far back the session history is used to estimate wait times.
+	historyWindow = 30 * 24 * time.Hour
+	// activeWindow is how recently an assistant must have been assigned a request to be counted as active.
+	activeWindow = 30 * time.Minute
+)
+
+// defaultSessionDurations are used for request types that have no session history in the guild.
+var defaultSessionDurations = map[string]time.Duration{
+	"help":    10 * time.Minute,
+	"approve": 5 * time.Minute,
+}
+
+// estimateWait estimates how long a student must wait until the requests ahead of them in the queue have been handled,
+// given the average session duration for each request type and the number of assistants on duty.
+func estimateWait(ahead []*models.HelpRequest, durations map[string]time.Duration, assistants int) time.Duration {
+	var total time.Duration
+	for _, req := range ahead {
+		duration, ok := durations[req.Type]
+		if !ok {
+			duration = defaultSessionDurations[req.Type]
+		}
+		if duration == 0 {
+			duration = defaultSessionDurations["help"]
+		}
+		total += duration
+	}
+	if assistants < 1 {
+		assistants = 1
+	}
+	return total / time.Duration(assistants)
+}
+
+// estimateWaitAt estimates the wait time for the student at the given position in the guild's queue.
+func (bot *HelpBot) estimateWaitAt(guildID string, pos int) (time.Duration, error) {
+	if pos <= 1 {
+		return 0, nil
+	}
+	ahead, err := bot.db.GetWaitingRequests(guildID, pos-1)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	durations, err := bot.db.GetAverageSessionDurations(guildID, now.Add(-historyWindow))
+	if err != nil {
+		return 0, err
+	}
+	onDuty, err := bot.db.GetOnDuty(guildID)
+	if err != nil {
+		return 0, err
+	}
+	// Fall back on recent activity if the assistants do not sign on duty
+	assistants := len(onDuty)
+	if assistants == 0 {
+		if assistants, err = bot.db.CountActiveAssistants(guildID, now.Add(-activeWindow)); err != nil {
+			return 0, err
+		}
+	}
+	return estimateWait(ahead, durations, assistants), nil
+}
+
+// waitMsg returns a message with the estimated wait time for the student at the given position in the guild's queue.
+func (bot *HelpBot) waitMsg(guildID string, pos int) string {
+	if pos == 1 {
+		return " You are next in line."
+	}
+	wait, err := bot.estimateWaitAt(guildID, pos)
+	if err != nil {
+		bot.log.Errorln("Failed to estimate wait time:", err)
+		return ""
+	}
+	if wait < time.Minute {
+		return " The estimated wait time is less than a minute."
+	}
+	return fmt.Sprintf(" The estimated wait time is about %d minutes.", int(wait.Round(time.Minute).Minutes()))
+}
@@ -1,12 +1,17 @@
 package helpbot
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Raytar/helpbot/database"
 	"github.com/Raytar/helpbot/models"
 	"github.com/bwmarrin/discordgo"
+	qfpb "github.com/quickfeed/quickfeed/qf"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -21,15 +26,22 @@ func (bot *HelpBot) initEvents() {
 			return
 		}
 		bot.log.Infof("Received interaction: %+v from user: %s", i, user.User.Username)
-		if i.Type != discordgo.InteractionApplicationCommand {
-			return
-		}
 
 		// ignore bot messages
 		if i.Member.User.Bot {
 			return
 		}
-		bot.discordMessageCreate(s, i)
+
+		switch i.Type {
+		case discordgo.InteractionApplicationCommand:
+			bot.discordMessageCreate(s, i)
+		case discordgo.InteractionModalSubmit:
+			bot.routeCustomID(bot.modals, i.ModalSubmitData().CustomID, i)
+		case discordgo.InteractionMessageComponent:
+			bot.routeCustomID(bot.components, i.MessageComponentData().CustomID, i)
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			bot.assignmentAutocomplete(i)
+		}
 	})
 
 	bot.client.AddHandler(bot.discordServerJoin)
@@ -90,6 +102,8 @@ func (bot *HelpBot) discordServerJoin(s *discordgo.Session, e *discordgo.GuildCr
 
 	// Create roles and commands for the server
 	_ = bot.initServer(s, e.ID)
+	// Bring the queue board up to date with changes made while the bot was offline
+	bot.queueChanged(e.ID)
 
 	// Announce that the bot is online and ready to help
 	// TODO: Might be best to send this to the server owner
@@ -105,14 +119,34 @@ func (bot *HelpBot) discordMessageCreate(s *discordgo.Session, m *discordgo.Inte
 		return
 	}
 
+	pendingReplies.Store(m.ID, &commandReply{})
+	defer bot.recordCommand(m)
+
 	if cmdFunc, ok := bot.commands[command]; ok {
+		start := time.Now()
 		cmdFunc(m)
+		observeCommand(command, time.Since(start))
 		return
 	}
 	replyMsg(bot.client, m, fmt.Sprintf("'%s' is not a recognized command. See /help for available commands.",
 		command))
 }
 
+// routeCustomID calls the function that handles interactions with the given custom ID.
+func (bot *HelpBot) routeCustomID(routes customIDMap, customID string, m *discordgo.InteractionCreate) {
+	prefix, _, _ := strings.Cut(customID, ":")
+
+	pendingReplies.Store(m.ID, &commandReply{})
+	defer bot.recordInteraction(m, customID)
+
+	if handler, ok := routes[prefix]; ok {
+		handler(m)
+		return
+	}
+	bot.log.Errorf("Received interaction with unknown custom ID: %s", customID)
+	replyMsg(bot.client, m, "Unknown request.")
+}
+
 func getMember(s *discordgo.Session, i *discordgo.InteractionCreate) *discordgo.Member {
 	if i.Member != nil {
 		return i.Member
@@ -128,6 +162,15 @@ func (bot *HelpBot) initServer(s *discordgo.Session, guildID string) error {
 	if err != nil {
 		return err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assignments, err := bot.qf.GetAssignments(ctx, course.CourseID)
+	if err != nil {
+		// Register the commands anyway; students can still request help without picking an assignment.
+		log.Errorln("Failed to get assignments from QuickFeed:", err)
+	}
+	bot.setAssignments(guildID, assignments)
+
 	commands := GetCommands(course)
 	// Register slash commands. If a command already exists, it will be updated.
 	for _, cmd := range commands {
@@ -185,27 +228,59 @@ func (bot *HelpBot) initServer(s *discordgo.Session, guildID string) error {
 	return nil
 }
 
-func (bot *HelpBot) createChannel(s *discordgo.Session, guildID, name string, roles ...string) error {
-	channel, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
-		Name: "test",
-		Type: discordgo.ChannelTypeGuildText,
-		PermissionOverwrites: []*discordgo.PermissionOverwrite{
-			{
-				ID:   bot.GetRole(guildID, RoleStudent),
-				Type: discordgo.PermissionOverwriteTypeRole,
-			},
-			{
-				ID:   guildID, // Everyone
-				Type: discordgo.PermissionOverwriteTypeRole,
-				Deny: discordgo.PermissionViewChannel,
-			},
-		},
-	})
+// setAssignments stores the assignments of the course configured for the guild.
+func (bot *HelpBot) setAssignments(guildID string, assignments []*qfpb.Assignment) {
+	bot.assignmentsMu.Lock()
+	defer bot.assignmentsMu.Unlock()
+	bot.assignments[guildID] = assignments
+}
+
+// getAssignments returns the assignments of the course configured for the guild.
+func (bot *HelpBot) getAssignments(guildID string) []*qfpb.Assignment {
+	bot.assignmentsMu.RLock()
+	defer bot.assignmentsMu.RUnlock()
+	return bot.assignments[guildID]
+}
+
+// loadAssignments returns the assignments of the course configured for the guild.
+// The assignments are fetched from QuickFeed if they could not be fetched when the bot joined the guild.
+func (bot *HelpBot) loadAssignments(guildID string) []*qfpb.Assignment {
+	if assignments := bot.getAssignments(guildID); len(assignments) > 0 {
+		return assignments
+	}
+	course, err := bot.db.GetCourse(&models.Course{GuildID: guildID})
 	if err != nil {
-		bot.log.Errorln("Failed to create channel:", err)
-		return err
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), quickFeedTimeout)
+	defer cancel()
+	assignments, err := bot.qf.GetAssignments(ctx, course.CourseID)
+	if err != nil {
+		bot.log.Errorln("Failed to get assignments from QuickFeed:", err)
+		return nil
+	}
+	bot.setAssignments(guildID, assignments)
+	return assignments
+}
+
+// findAssignment returns the guild's assignment with the given ID or name, or nil if there is none.
+func (bot *HelpBot) findAssignment(guildID, value string) *qfpb.Assignment {
+	id, _ := strconv.ParseUint(value, 10, 64)
+	for _, assignment := range bot.loadAssignments(guildID) {
+		if assignment.GetID() == id || strings.EqualFold(assignment.GetName(), value) {
+			return assignment
+		}
+	}
+	return nil
+}
+
+// getAssignment returns the assignment with the given ID from the course configured for the guild.
+func (bot *HelpBot) getAssignment(guildID string, assignmentID uint64) *qfpb.Assignment {
+	for _, assignment := range bot.loadAssignments(guildID) {
+		if assignment.GetID() == assignmentID {
+			return assignment
+		}
 	}
-	bot.log.Infof("Created channel: %s", channel.Name)
 	return nil
 }
 
@@ -0,0 +1,187 @@
+package helpbot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// dateLayout is the layout of the dates accepted by the export.
+const dateLayout = "2006-01-02"
+
+// ExportRecord is the exported form of a help request. Students are identified by pseudonyms.
+type ExportRecord struct {
+	ID               uint     `json:"id"`
+	Type             string   `json:"type"`
+	Assignment       string   `json:"assignment,omitempty"`
+	Status           string   `json:"status"`
+	Reason           string   `json:"reason,omitempty"`
+	CreatedAt        string   `json:"created_at"`
+	AssignedAt       string   `json:"assigned_at,omitempty"`
+	DoneAt           string   `json:"done_at,omitempty"`
+	Assistant        string   `json:"assistant,omitempty"`
+	Student          string   `json:"student"`
+	Group            string   `json:"group,omitempty"`
+	GroupMembers     []string `json:"group_members,omitempty"`
+	SubmissionStatus string   `json:"submission_status,omitempty"`
+}
+
+var exportHeader = []string{
+	"id", "type", "assignment", "status", "reason", "created_at", "assigned_at", "done_at",
+	"assistant", "student", "group", "group_members", "submission_status",
+}
+
+// Pseudonym returns a stable pseudonym for the identifier. The pseudonyms cannot be linked to the
+// identifiers without the key, and the same key must be used to compare pseudonyms across exports.
+func Pseudonym(key []byte, id string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// formatTime formats a time for the export, or returns the empty string for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// newExportRecord returns the exported form of the request, with the students' identifiers pseudonymised using the key.
+func newExportRecord(req *models.HelpRequest, key []byte) *ExportRecord {
+	record := &ExportRecord{
+		ID:               req.ID,
+		Type:             req.Type,
+		Assignment:       req.AssignmentName,
+		Status:           string(req.Status),
+		Reason:           req.Reason,
+		CreatedAt:        formatTime(req.CreatedAt),
+		AssignedAt:       formatTime(req.AssignedAt),
+		DoneAt:           formatTime(req.DoneAt),
+		Assistant:        req.AssistantUserID,
+		Student:          Pseudonym(key, req.StudentUserID),
+		SubmissionStatus: req.SubmissionStatus,
+	}
+	if req.GroupID != 0 {
+		record.Group = Pseudonym(key, "group:"+strconv.FormatUint(req.GroupID, 10))
+	}
+	for _, id := range req.GroupMemberIDs {
+		record.GroupMembers = append(record.GroupMembers, Pseudonym(key, id))
+	}
+	return record
+}
+
+// ExportRequests writes the requests to w in the given format, i.e., "csv" or "json".
+// The students' identifiers are pseudonymised using the key.
+func ExportRequests(w io.Writer, requests []*models.HelpRequest, format string, key []byte) error {
+	if len(key) == 0 {
+		return fmt.Errorf("an export key is required to pseudonymise the students")
+	}
+	records := make([]*ExportRecord, len(requests))
+	for i, req := range requests {
+		records[i] = newExportRecord(req, key)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportHeader); err != nil {
+			return err
+		}
+		for _, r := range records {
+			row := []string{
+				strconv.FormatUint(uint64(r.ID), 10), r.Type, r.Assignment, r.Status, r.Reason, r.CreatedAt, r.AssignedAt, r.DoneAt,
+				r.Assistant, r.Student, r.Group, strings.Join(r.GroupMembers, ";"), r.SubmissionStatus,
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+// ParseDateRange parses the first and last day (YYYY-MM-DD) of a date range in the given timezone,
+// and returns the start of the first day and the end of the last day. Empty dates leave the range open.
+func ParseDateRange(from, to string, loc *time.Location) (start, end time.Time, err error) {
+	if from != "" {
+		if start, err = time.ParseInLocation(dateLayout, from, loc); err != nil {
+			return start, end, fmt.Errorf("invalid date %q, use YYYY-MM-DD", from)
+		}
+	}
+	if to != "" {
+		if end, err = time.ParseInLocation(dateLayout, to, loc); err != nil {
+			return start, end, fmt.Errorf("invalid date %q, use YYYY-MM-DD", to)
+		}
+		end = end.AddDate(0, 0, 1)
+		if !end.After(start) {
+			return start, end, fmt.Errorf("the range must end after it starts")
+		}
+	}
+	return start, end, nil
+}
+
+func (bot *HelpBot) exportCommand(m *discordgo.InteractionCreate) {
+	format := "csv"
+	if opt := getOption(m, "format"); opt != nil {
+		format = opt.StringValue()
+	}
+	var from, to string
+	if opt := getOption(m, "from"); opt != nil {
+		from = opt.StringValue()
+	}
+	if opt := getOption(m, "to"); opt != nil {
+		to = opt.StringValue()
+	}
+	if bot.cfg.ExportKey == "" {
+		replyMsg(bot.client, m, "Exports are not enabled. An export key must be set in the bot's configuration.")
+		return
+	}
+	start, end, err := ParseDateRange(from, to, bot.location)
+	if err != nil {
+		replyMsg(bot.client, m, err.Error())
+		return
+	}
+
+	requests, err := bot.db.GetHelpRequests(m.GuildID, start, end)
+	if err != nil {
+		replyMsg(bot.client, m, "Failed to get the help requests.")
+		return
+	}
+	var buf bytes.Buffer
+	if err := ExportRequests(&buf, requests, format, []byte(bot.cfg.ExportKey)); err != nil {
+		bot.log.Errorln("Failed to export help requests:", err)
+		replyMsg(bot.client, m, "Failed to export the help requests.")
+		return
+	}
+
+	contentType := "text/csv"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	reply(bot.client, m, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content: fmt.Sprintf("Exported %d help requests.", len(requests)),
+		Files: []*discordgo.File{{
+			Name:        fmt.Sprintf("helprequests-%s.%s", time.Now().In(bot.location).Format(dateLayout), format),
+			ContentType: contentType,
+			Reader:      &buf,
+		}},
+	})
+}
@@ -34,6 +34,18 @@ func (bot *HelpBot) hasRoles(guildID string, gm *discordgo.Member, roles ...stri
 	return false
 }
 
+// isAssistant returns true if the user is a member of the guild with the assistant role.
+// The member is looked up in the session's cache first, and fetched from Discord otherwise.
+func (bot *HelpBot) isAssistant(guildID, userID string) bool {
+	member, err := bot.client.State.Member(guildID, userID)
+	if err != nil {
+		if member, err = bot.client.GuildMember(guildID, userID); err != nil {
+			return false
+		}
+	}
+	return bot.hasRoles(guildID, member, RoleAssistant)
+}
+
 func (bot *HelpBot) GetRole(guildID, roleName string) string {
 	return bot.roles[guildID][roleName]
 }
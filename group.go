@@ -0,0 +1,74 @@
+package helpbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/Raytar/helpbot/models"
+)
+
+// errNoGroup is returned by setGroup if the student is not in a group in the course on QuickFeed.
+var errNoGroup = errors.New("you are not in a group on QuickFeed")
+
+// setGroup makes the request on behalf of the student's QuickFeed group.
+// The Discord user IDs of the group's registered members are stored on the request so that they can be notified.
+func (bot *HelpBot) setGroup(ctx context.Context, req *models.HelpRequest) error {
+	course, err := bot.db.GetCourse(&models.Course{GuildID: req.GuildID})
+	if err != nil {
+		return fmt.Errorf("this server is not configured with a course")
+	}
+	student, err := bot.db.GetGuildStudent(req.GuildID, req.StudentUserID)
+	if err != nil || student == nil {
+		return fmt.Errorf("you are not registered")
+	}
+	userID, err := bot.getQuickFeedID(ctx, course, student)
+	if err != nil {
+		bot.log.Errorln("Failed to get QuickFeed user:", err)
+		return fmt.Errorf("failed to find you on QuickFeed")
+	}
+	group, err := bot.qf.GetGroup(ctx, course.CourseID, userID)
+	if connect.CodeOf(err) == connect.CodeNotFound || (err == nil && group.GetID() == 0) {
+		return errNoGroup
+	}
+	if err != nil {
+		bot.log.Errorln("Failed to get group from QuickFeed:", err)
+		return fmt.Errorf("failed to find your group on QuickFeed")
+	}
+
+	var logins []string
+	for _, user := range group.GetUsers() {
+		logins = append(logins, user.GetLogin())
+	}
+	members, err := bot.db.GetStudentsByLogin(req.GuildID, logins)
+	if err != nil {
+		return fmt.Errorf("failed to get the members of your group")
+	}
+
+	req.GroupID = group.GetID()
+	req.GroupName = group.GetName()
+	req.GroupMemberIDs = nil
+	for _, member := range members {
+		if member.UserID != req.StudentUserID {
+			req.GroupMemberIDs = append(req.GroupMemberIDs, member.UserID)
+		}
+	}
+	return nil
+}
+
+// notifyStudents sends a direct message to the student who made the request, and to the other
+// registered members of the group if the request was made on behalf of a group.
+func (bot *HelpBot) notifyStudents(req *models.HelpRequest, msg string) {
+	for _, userID := range append([]string{req.StudentUserID}, req.GroupMemberIDs...) {
+		bot.dm.send(userID, msg)
+	}
+}
+
+// forGroup returns a suffix naming the request's group, if any.
+func forGroup(req *models.HelpRequest) string {
+	if req.GroupName == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (group %s)", req.GroupName)
+}
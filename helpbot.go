@@ -3,6 +3,8 @@ package helpbot
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/Raytar/helpbot/database"
@@ -18,6 +20,20 @@ type Config struct {
 	AppID     string `json:"app_id"`
 	GHToken   string `json:"auth_token"`
 	QuickFeed bool   `json:"quickfeed"`
+	// Timezone of the lab hours, e.g., "Europe/Oslo". The local timezone is used if empty.
+	Timezone string `json:"timezone"`
+	// ExportKey is the secret used to pseudonymise students in exports. Exports are disabled if empty.
+	ExportKey string `json:"export_key"`
+	// APIAddress is the address to serve the queue API on, e.g., ":8080". The API is disabled if empty.
+	APIAddress string `json:"api_address"`
+	// APIToken must be given as a bearer token by the clients of the queue API.
+	APIToken string `json:"api_token"`
+	// DashboardToken gives read-only access to the dashboards. The dashboards are disabled if empty.
+	DashboardToken string `json:"dashboard_token"`
+	// MetricsToken gives read-only access to the Prometheus metrics. The metrics are disabled if empty.
+	MetricsToken string `json:"metrics_token"`
+	// AuditRetentionDays is the number of days that audit log entries are kept. The default is 90 days.
+	AuditRetentionDays int `json:"audit_retention_days"`
 }
 
 type HelpBot struct {
@@ -26,26 +42,57 @@ type HelpBot struct {
 	db      *database.Database
 	qf      *QuickFeed
 	log     *logrus.Logger
+	dm      *dmSender
 	courses []*qfpb.Course
 
 	// role mappings
 	roles map[string]map[string]string
 
+	// assignments of the course configured for each guild. key is the guild ID
+	assignments map[string][]*qfpb.Assignment
+	// assignmentsMu protects assignments, which is read by the queue API while the Discord events update it
+	assignmentsMu sync.RWMutex
+
+	// location is the timezone of the lab hours
+	location *time.Location
+	// whether each guild was within its lab hours the last time the schedule was checked. key is the guild ID
+	labHoursActive map[string]bool
+
+	// boardMu serializes updates to the queue boards
+	boardMu sync.Mutex
+	// dashboard notifies the web dashboards when a queue changes
+	dashboard dashboardHub
+
 	// command mappings. key is the command name, value is the function to call
 	commands commandMap
+	// modal mappings. key is the prefix of the modal's custom ID, value is the function to call
+	modals customIDMap
+	// message component mappings. key is the prefix of the component's custom ID, value is the function to call
+	components customIDMap
 }
 
 func (bot *HelpBot) Connect(ctx context.Context) error {
 	if bot.client == nil {
 		return fmt.Errorf("Discord client is not initialized")
 	}
-	return bot.client.Open()
+	if err := bot.client.Open(); err != nil {
+		return err
+	}
+	go bot.runSchedule(ctx)
+	go bot.dm.run(ctx)
+	if bot.cfg.APIAddress != "" {
+		go bot.serveHTTP(ctx)
+	}
+	return nil
 }
 
 func (bot *HelpBot) Disconnect() error {
 	return bot.client.Close()
 }
 
+// maxChoices is the maximum number of choices Discord allows for a command option.
+const maxChoices = 25
+
 func GetCommands(course *models.Course) []*discordgo.ApplicationCommand {
 	courseChoices := []*discordgo.ApplicationCommandOptionChoice{
 		{
@@ -97,17 +144,54 @@ func GetCommands(course *models.Course) []*discordgo.ApplicationCommand {
 			Name:                     "gethelp",
 			DefaultMemberPermissions: &permStudent,
 			Description:              "Get help from a teaching assistant.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "assignment",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the assignment you need help with",
+					Required:    false,
+					// The assignments are suggested as the student types, see assignmentAutocomplete
+					Autocomplete: true,
+				},
+				{
+					Name:        "group",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Description: "make the request on behalf of your group (default: true for group assignments)",
+					Required:    false,
+				},
+			},
 		},
 		{
 			Name:                     "approve",
 			DefaultMemberPermissions: &permStudent,
 			Description:              "Get your lab approved by a teaching assistant.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "assignment",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the assignment you want approved",
+					Required:    true,
+					// The assignments are suggested as the student types, see assignmentAutocomplete
+					Autocomplete: true,
+				},
+				{
+					Name:        "group",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Description: "make the request on behalf of your group (default: true for group assignments)",
+					Required:    false,
+				},
+			},
 		},
 		{
 			Name:                     "cancel",
 			DefaultMemberPermissions: &permStudent,
 			Description:              "Cancels a pending request for help and removes you from the queue.",
 		},
+		{
+			Name:                     "describe",
+			DefaultMemberPermissions: &permStudent,
+			Description:              "Edit the description of your help request while you are waiting.",
+		},
 		{
 			Name:                     "status",
 			DefaultMemberPermissions: &permStudent,
@@ -126,10 +210,338 @@ func GetCommands(course *models.Course) []*discordgo.ApplicationCommand {
 				},
 			},
 		},
+		{
+			Name:                     "onduty",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Start your shift as a teaching assistant.",
+		},
+		{
+			Name:                     "offduty",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "End your shift as a teaching assistant.",
+		},
+		{
+			Name:                     "length",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Get the number of students waiting in each lane of the queue.",
+		},
 		{
 			Name:                     "next",
 			DefaultMemberPermissions: &permAssistant,
 			Description:              "Get the next student in the queue.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "lane",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "only take requests from this lane",
+					Required:    false,
+					Choices:     laneChoices(),
+				},
+			},
+		},
+		{
+			Name:                     "take",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Take a specific student from the queue.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "student",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Description: "the student whose request you want to take",
+					Required:    false,
+				},
+				{
+					Name:        "id",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Description: "the ID of the request, as shown by /list",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "done",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Close your current session with a student.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "outcome",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the outcome of the session (default: resolved)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "resolved", Value: string(models.StatusResolved)},
+						{Name: "no-show", Value: string(models.StatusNoShow)},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "skip",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Close your current session as a no-show and get the next student in the queue.",
+		},
+		{
+			Name:                     "grade",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Set the status of the submission in your current approval session on QuickFeed.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "status",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the new status of the submission",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "approved", Value: "approved"},
+						{Name: "rejected", Value: "rejected"},
+						{Name: "revision", Value: "revision"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "scorelimit",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Set whether students must reach the assignment's score limit to request approval.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "enforce",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Description: "Refuse approval requests for submissions below the score limit.",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "stats",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Show statistics of the requests handled by each teaching assistant.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "period",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the period to show statistics for (default: today)",
+					Required:    false,
+					Choices:     statsPeriods,
+				},
+			},
+		},
+		{
+			Name:                     "export",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Export the help requests to a file, with pseudonymised students.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "format",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the file format (default: csv)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV", Value: "csv"},
+						{Name: "JSON", Value: "json"},
+					},
+				},
+				{
+					Name:        "from",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the first day to export (YYYY-MM-DD)",
+					Required:    false,
+				},
+				{
+					Name:        "to",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the last day to export (YYYY-MM-DD)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "audit",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Show the audit log of a user's commands and changes to their requests.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "user",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Description: "the user whose audit log you want to see",
+					Required:    true,
+				},
+				{
+					Name:        "number",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Description: "the number of entries to show (default: 10)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "strategy",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Set how new requests are assigned when several teaching assistants are waiting.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "strategy",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "the assignment strategy",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "longest waiting", Value: string(models.StrategyFIFO)},
+						{Name: "round-robin", Value: string(models.StrategyRoundRobin)},
+						{Name: "fewest sessions today", Value: string(models.StrategyLeastLoaded)},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "open",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Open the queue for new requests.",
+		},
+		{
+			Name:                     "close",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Close the queue for new requests. It is opened automatically when lab hours start.",
+		},
+		{
+			Name:                     "freeze",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Close the queue for new requests until it is opened with /open.",
+		},
+		{
+			Name:                     "lane",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Manage the priority of the lanes in the queue.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "set",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Set the priority and weight of a lane.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "name",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Description: "the lane, i.e., the request type",
+							Required:    true,
+							Choices:     laneChoices(),
+						},
+						{
+							Name:        "priority",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Description: "requests are only taken from lanes with lower priority when higher lanes are empty",
+							Required:    true,
+						},
+						{
+							Name:        "weight",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Description: "how much faster requests move up compared to lanes with the same priority (default: 1)",
+							Required:    false,
+							MinValue:    &minWeight,
+						},
+					},
+				},
+				{
+					Name:        "list",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "List the lanes.",
+				},
+				{
+					Name:        "remove",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Remove a lane, giving its requests the default priority and weight.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "name",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Description: "the lane, i.e., the request type",
+							Required:    true,
+							Choices:     laneChoices(),
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "labhours",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Manage the weekly lab hours during which the queue is open.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "add",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Add lab hours.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "day",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Description: "the day of the week",
+							Required:    true,
+							Choices:     weekdayChoices,
+						},
+						{
+							Name:        "start",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Description: "the time the lab hours start (HH:MM)",
+							Required:    true,
+						},
+						{
+							Name:        "end",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Description: "the time the lab hours end (HH:MM)",
+							Required:    true,
+						},
+					},
+				},
+				{
+					Name:        "list",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "List the lab hours.",
+				},
+				{
+					Name:        "remove",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Remove lab hours.",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "id",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Description: "the id of the lab hours, as shown by /labhours list",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "sessionchannel",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Set the channel in which private threads are created for help sessions.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:         "channel",
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Description:  "the channel for session threads (default: the channel where the session starts)",
+					Required:     false,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "board",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Create a queue board that is updated whenever the queue changes.",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:         "channel",
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Description:  "the channel for the queue board",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "cancel-waiting",
+			DefaultMemberPermissions: &permAssistant,
+			Description:              "Stop waiting for the next student to request help.",
 		},
 		{
 			Name:                     "clear",
@@ -142,6 +554,12 @@ func GetCommands(course *models.Course) []*discordgo.ApplicationCommand {
 					Description: "Confirm that you want to clear the queue. This cannot be undone.",
 					Required:    true,
 				},
+				{
+					Name:        "reason",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Description: "The reason for clearing the queue, sent to each student in the queue.",
+					Required:    false,
+				},
 			},
 		},
 		{
@@ -193,15 +611,35 @@ var (
 )
 
 func New(cfg Config, log *logrus.Logger, qf *QuickFeed) (bot *HelpBot, err error) {
-	bot = &HelpBot{cfg: cfg, log: log, qf: qf, roles: make(map[string]map[string]string)}
+	bot = &HelpBot{
+		cfg:            cfg,
+		log:            log,
+		qf:             qf,
+		roles:          make(map[string]map[string]string),
+		assignments:    make(map[string][]*qfpb.Assignment),
+		location:       time.Local,
+		labHoursActive: make(map[string]bool),
+	}
+
+	if cfg.APIAddress != "" && cfg.APIToken == "" {
+		return nil, fmt.Errorf("api_token must be set to serve the queue API")
+	}
+
+	if cfg.Timezone != "" {
+		if bot.location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
 
 	if bot.client, err = discordgo.New("Bot " + cfg.Token); err != nil {
 		return nil, err
 	}
+	bot.dm = newDMSender(bot.client, log)
 
 	if bot.db, err = database.OpenDatabase(cfg.DBPath, log); err != nil {
 		return nil, err
 	}
+	bot.db.SetLocation(bot.location)
 
 	if courses, err := bot.qf.qf.GetCourses(context.Background(), &connect.Request[qfpb.Void]{}); err != nil {
 		return nil, err
@@ -220,6 +658,7 @@ func New(cfg Config, log *logrus.Logger, qf *QuickFeed) (bot *HelpBot, err error
 	})
 
 	bot.initCommands()
+	bot.initCommandMetrics()
 	bot.initEvents()
 
 	return bot, nil
@@ -0,0 +1,233 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: helpbot/v1/queue.proto
+
+package helpbotv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/Raytar/helpbot/helpbot/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// QueueServiceName is the fully-qualified name of the QueueService service.
+	QueueServiceName = "helpbot.v1.QueueService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// QueueServiceListQueueProcedure is the fully-qualified name of the QueueService's ListQueue RPC.
+	QueueServiceListQueueProcedure = "/helpbot.v1.QueueService/ListQueue"
+	// QueueServiceGetPositionProcedure is the fully-qualified name of the QueueService's GetPosition
+	// RPC.
+	QueueServiceGetPositionProcedure = "/helpbot.v1.QueueService/GetPosition"
+	// QueueServiceEnqueueProcedure is the fully-qualified name of the QueueService's Enqueue RPC.
+	QueueServiceEnqueueProcedure = "/helpbot.v1.QueueService/Enqueue"
+	// QueueServiceCancelProcedure is the fully-qualified name of the QueueService's Cancel RPC.
+	QueueServiceCancelProcedure = "/helpbot.v1.QueueService/Cancel"
+	// QueueServiceAssignProcedure is the fully-qualified name of the QueueService's Assign RPC.
+	QueueServiceAssignProcedure = "/helpbot.v1.QueueService/Assign"
+)
+
+// QueueServiceClient is a client for the helpbot.v1.QueueService service.
+type QueueServiceClient interface {
+	// ListQueue returns the queue state, the waiting requests, the sessions in progress, and the number of teaching assistants on duty.
+	ListQueue(context.Context, *connect.Request[v1.ListQueueRequest]) (*connect.Response[v1.ListQueueResponse], error)
+	// GetPosition returns the student's position in the queue, estimated wait time and active request.
+	GetPosition(context.Context, *connect.Request[v1.GetPositionRequest]) (*connect.Response[v1.GetPositionResponse], error)
+	// Enqueue creates a request for a registered student. The request is checked like the gethelp and approve commands.
+	Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error)
+	// Cancel cancels the student's waiting request.
+	Cancel(context.Context, *connect.Request[v1.CancelRequest]) (*connect.Response[v1.CancelResponse], error)
+	// Assign assigns the request, or the next request in the queue or lane, to the teaching assistant.
+	// The assistant must be a member of the guild with the Teaching Assistant role.
+	Assign(context.Context, *connect.Request[v1.AssignRequest]) (*connect.Response[v1.AssignResponse], error)
+}
+
+// NewQueueServiceClient constructs a client for the helpbot.v1.QueueService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewQueueServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) QueueServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	queueServiceMethods := v1.File_helpbot_v1_queue_proto.Services().ByName("QueueService").Methods()
+	return &queueServiceClient{
+		listQueue: connect.NewClient[v1.ListQueueRequest, v1.ListQueueResponse](
+			httpClient,
+			baseURL+QueueServiceListQueueProcedure,
+			connect.WithSchema(queueServiceMethods.ByName("ListQueue")),
+			connect.WithClientOptions(opts...),
+		),
+		getPosition: connect.NewClient[v1.GetPositionRequest, v1.GetPositionResponse](
+			httpClient,
+			baseURL+QueueServiceGetPositionProcedure,
+			connect.WithSchema(queueServiceMethods.ByName("GetPosition")),
+			connect.WithClientOptions(opts...),
+		),
+		enqueue: connect.NewClient[v1.EnqueueRequest, v1.EnqueueResponse](
+			httpClient,
+			baseURL+QueueServiceEnqueueProcedure,
+			connect.WithSchema(queueServiceMethods.ByName("Enqueue")),
+			connect.WithClientOptions(opts...),
+		),
+		cancel: connect.NewClient[v1.CancelRequest, v1.CancelResponse](
+			httpClient,
+			baseURL+QueueServiceCancelProcedure,
+			connect.WithSchema(queueServiceMethods.ByName("Cancel")),
+			connect.WithClientOptions(opts...),
+		),
+		assign: connect.NewClient[v1.AssignRequest, v1.AssignResponse](
+			httpClient,
+			baseURL+QueueServiceAssignProcedure,
+			connect.WithSchema(queueServiceMethods.ByName("Assign")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// queueServiceClient implements QueueServiceClient.
+type queueServiceClient struct {
+	listQueue   *connect.Client[v1.ListQueueRequest, v1.ListQueueResponse]
+	getPosition *connect.Client[v1.GetPositionRequest, v1.GetPositionResponse]
+	enqueue     *connect.Client[v1.EnqueueRequest, v1.EnqueueResponse]
+	cancel      *connect.Client[v1.CancelRequest, v1.CancelResponse]
+	assign      *connect.Client[v1.AssignRequest, v1.AssignResponse]
+}
+
+// ListQueue calls helpbot.v1.QueueService.ListQueue.
+func (c *queueServiceClient) ListQueue(ctx context.Context, req *connect.Request[v1.ListQueueRequest]) (*connect.Response[v1.ListQueueResponse], error) {
+	return c.listQueue.CallUnary(ctx, req)
+}
+
+// GetPosition calls helpbot.v1.QueueService.GetPosition.
+func (c *queueServiceClient) GetPosition(ctx context.Context, req *connect.Request[v1.GetPositionRequest]) (*connect.Response[v1.GetPositionResponse], error) {
+	return c.getPosition.CallUnary(ctx, req)
+}
+
+// Enqueue calls helpbot.v1.QueueService.Enqueue.
+func (c *queueServiceClient) Enqueue(ctx context.Context, req *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error) {
+	return c.enqueue.CallUnary(ctx, req)
+}
+
+// Cancel calls helpbot.v1.QueueService.Cancel.
+func (c *queueServiceClient) Cancel(ctx context.Context, req *connect.Request[v1.CancelRequest]) (*connect.Response[v1.CancelResponse], error) {
+	return c.cancel.CallUnary(ctx, req)
+}
+
+// Assign calls helpbot.v1.QueueService.Assign.
+func (c *queueServiceClient) Assign(ctx context.Context, req *connect.Request[v1.AssignRequest]) (*connect.Response[v1.AssignResponse], error) {
+	return c.assign.CallUnary(ctx, req)
+}
+
+// QueueServiceHandler is an implementation of the helpbot.v1.QueueService service.
+type QueueServiceHandler interface {
+	// ListQueue returns the queue state, the waiting requests, the sessions in progress, and the number of teaching assistants on duty.
+	ListQueue(context.Context, *connect.Request[v1.ListQueueRequest]) (*connect.Response[v1.ListQueueResponse], error)
+	// GetPosition returns the student's position in the queue, estimated wait time and active request.
+	GetPosition(context.Context, *connect.Request[v1.GetPositionRequest]) (*connect.Response[v1.GetPositionResponse], error)
+	// Enqueue creates a request for a registered student. The request is checked like the gethelp and approve commands.
+	Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error)
+	// Cancel cancels the student's waiting request.
+	Cancel(context.Context, *connect.Request[v1.CancelRequest]) (*connect.Response[v1.CancelResponse], error)
+	// Assign assigns the request, or the next request in the queue or lane, to the teaching assistant.
+	// The assistant must be a member of the guild with the Teaching Assistant role.
+	Assign(context.Context, *connect.Request[v1.AssignRequest]) (*connect.Response[v1.AssignResponse], error)
+}
+
+// NewQueueServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewQueueServiceHandler(svc QueueServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	queueServiceMethods := v1.File_helpbot_v1_queue_proto.Services().ByName("QueueService").Methods()
+	queueServiceListQueueHandler := connect.NewUnaryHandler(
+		QueueServiceListQueueProcedure,
+		svc.ListQueue,
+		connect.WithSchema(queueServiceMethods.ByName("ListQueue")),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceGetPositionHandler := connect.NewUnaryHandler(
+		QueueServiceGetPositionProcedure,
+		svc.GetPosition,
+		connect.WithSchema(queueServiceMethods.ByName("GetPosition")),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceEnqueueHandler := connect.NewUnaryHandler(
+		QueueServiceEnqueueProcedure,
+		svc.Enqueue,
+		connect.WithSchema(queueServiceMethods.ByName("Enqueue")),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceCancelHandler := connect.NewUnaryHandler(
+		QueueServiceCancelProcedure,
+		svc.Cancel,
+		connect.WithSchema(queueServiceMethods.ByName("Cancel")),
+		connect.WithHandlerOptions(opts...),
+	)
+	queueServiceAssignHandler := connect.NewUnaryHandler(
+		QueueServiceAssignProcedure,
+		svc.Assign,
+		connect.WithSchema(queueServiceMethods.ByName("Assign")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/helpbot.v1.QueueService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case QueueServiceListQueueProcedure:
+			queueServiceListQueueHandler.ServeHTTP(w, r)
+		case QueueServiceGetPositionProcedure:
+			queueServiceGetPositionHandler.ServeHTTP(w, r)
+		case QueueServiceEnqueueProcedure:
+			queueServiceEnqueueHandler.ServeHTTP(w, r)
+		case QueueServiceCancelProcedure:
+			queueServiceCancelHandler.ServeHTTP(w, r)
+		case QueueServiceAssignProcedure:
+			queueServiceAssignHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedQueueServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedQueueServiceHandler struct{}
+
+func (UnimplementedQueueServiceHandler) ListQueue(context.Context, *connect.Request[v1.ListQueueRequest]) (*connect.Response[v1.ListQueueResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("helpbot.v1.QueueService.ListQueue is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) GetPosition(context.Context, *connect.Request[v1.GetPositionRequest]) (*connect.Response[v1.GetPositionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("helpbot.v1.QueueService.GetPosition is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Enqueue(context.Context, *connect.Request[v1.EnqueueRequest]) (*connect.Response[v1.EnqueueResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("helpbot.v1.QueueService.Enqueue is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Cancel(context.Context, *connect.Request[v1.CancelRequest]) (*connect.Response[v1.CancelResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("helpbot.v1.QueueService.Cancel is not implemented"))
+}
+
+func (UnimplementedQueueServiceHandler) Assign(context.Context, *connect.Request[v1.AssignRequest]) (*connect.Response[v1.AssignResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("helpbot.v1.QueueService.Assign is not implemented"))
+}
@@ -0,0 +1,852 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: helpbot/v1/queue.proto
+
+package helpbotv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// QueueRequest is a help request.
+type QueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	StudentId     string                 `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	AssistantId   string                 `protobuf:"bytes,3,opt,name=assistant_id,json=assistantId,proto3" json:"assistant_id,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Assignment    string                 `protobuf:"bytes,5,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	Group         string                 `protobuf:"bytes,6,opt,name=group,proto3" json:"group,omitempty"`
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	Description   string                 `protobuf:"bytes,8,opt,name=description,proto3" json:"description,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	AssignedAt    *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=assigned_at,json=assignedAt,proto3" json:"assigned_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueRequest) Reset() {
+	*x = QueueRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueRequest) ProtoMessage() {}
+
+func (x *QueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueRequest.ProtoReflect.Descriptor instead.
+func (*QueueRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *QueueRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *QueueRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetAssistantId() string {
+	if x != nil {
+		return x.AssistantId
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetAssignment() string {
+	if x != nil {
+		return x.Assignment
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *QueueRequest) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *QueueRequest) GetAssignedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AssignedAt
+	}
+	return nil
+}
+
+type ListQueueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQueueRequest) Reset() {
+	*x = ListQueueRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQueueRequest) ProtoMessage() {}
+
+func (x *ListQueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQueueRequest.ProtoReflect.Descriptor instead.
+func (*ListQueueRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListQueueRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+type ListQueueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QueueState    string                 `protobuf:"bytes,1,opt,name=queue_state,json=queueState,proto3" json:"queue_state,omitempty"`
+	Waiting       []*QueueRequest        `protobuf:"bytes,2,rep,name=waiting,proto3" json:"waiting,omitempty"`
+	Sessions      []*QueueRequest        `protobuf:"bytes,3,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	OnDuty        int32                  `protobuf:"varint,4,opt,name=on_duty,json=onDuty,proto3" json:"on_duty,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQueueResponse) Reset() {
+	*x = ListQueueResponse{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQueueResponse) ProtoMessage() {}
+
+func (x *ListQueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQueueResponse.ProtoReflect.Descriptor instead.
+func (*ListQueueResponse) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListQueueResponse) GetQueueState() string {
+	if x != nil {
+		return x.QueueState
+	}
+	return ""
+}
+
+func (x *ListQueueResponse) GetWaiting() []*QueueRequest {
+	if x != nil {
+		return x.Waiting
+	}
+	return nil
+}
+
+func (x *ListQueueResponse) GetSessions() []*QueueRequest {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+func (x *ListQueueResponse) GetOnDuty() int32 {
+	if x != nil {
+		return x.OnDuty
+	}
+	return 0
+}
+
+type GetPositionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	StudentId     string                 `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPositionRequest) Reset() {
+	*x = GetPositionRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPositionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPositionRequest) ProtoMessage() {}
+
+func (x *GetPositionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPositionRequest.ProtoReflect.Descriptor instead.
+func (*GetPositionRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPositionRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *GetPositionRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+type GetPositionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Position is 0 if the student has no request waiting in the queue.
+	Position             int32         `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	EstimatedWaitSeconds int64         `protobuf:"varint,2,opt,name=estimated_wait_seconds,json=estimatedWaitSeconds,proto3" json:"estimated_wait_seconds,omitempty"`
+	Request              *QueueRequest `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *GetPositionResponse) Reset() {
+	*x = GetPositionResponse{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPositionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPositionResponse) ProtoMessage() {}
+
+func (x *GetPositionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPositionResponse.ProtoReflect.Descriptor instead.
+func (*GetPositionResponse) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPositionResponse) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *GetPositionResponse) GetEstimatedWaitSeconds() int64 {
+	if x != nil {
+		return x.EstimatedWaitSeconds
+	}
+	return 0
+}
+
+func (x *GetPositionResponse) GetRequest() *QueueRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type EnqueueRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	GuildId      string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	StudentId    string                 `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	Type         string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	AssignmentId uint64                 `protobuf:"varint,4,opt,name=assignment_id,json=assignmentId,proto3" json:"assignment_id,omitempty"`
+	Description  string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// Group makes the request on behalf of the student's group. The default is true for group assignments.
+	Group         *bool `protobuf:"varint,6,opt,name=group,proto3,oneof" json:"group,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueRequest) Reset() {
+	*x = EnqueueRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueRequest) ProtoMessage() {}
+
+func (x *EnqueueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueRequest.ProtoReflect.Descriptor instead.
+func (*EnqueueRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *EnqueueRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *EnqueueRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+func (x *EnqueueRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EnqueueRequest) GetAssignmentId() uint64 {
+	if x != nil {
+		return x.AssignmentId
+	}
+	return 0
+}
+
+func (x *EnqueueRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *EnqueueRequest) GetGroup() bool {
+	if x != nil && x.Group != nil {
+		return *x.Group
+	}
+	return false
+}
+
+type EnqueueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Request       *QueueRequest          `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Position      int32                  `protobuf:"varint,2,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueResponse) Reset() {
+	*x = EnqueueResponse{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueResponse) ProtoMessage() {}
+
+func (x *EnqueueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueResponse.ProtoReflect.Descriptor instead.
+func (*EnqueueResponse) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EnqueueResponse) GetRequest() *QueueRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *EnqueueResponse) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type CancelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	StudentId     string                 `protobuf:"bytes,2,opt,name=student_id,json=studentId,proto3" json:"student_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRequest) Reset() {
+	*x = CancelRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRequest) ProtoMessage() {}
+
+func (x *CancelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRequest.ProtoReflect.Descriptor instead.
+func (*CancelRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CancelRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *CancelRequest) GetStudentId() string {
+	if x != nil {
+		return x.StudentId
+	}
+	return ""
+}
+
+type CancelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelResponse) Reset() {
+	*x = CancelResponse{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelResponse) ProtoMessage() {}
+
+func (x *CancelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelResponse.ProtoReflect.Descriptor instead.
+func (*CancelResponse) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{8}
+}
+
+type AssignRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	GuildId     string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	AssistantId string                 `protobuf:"bytes,2,opt,name=assistant_id,json=assistantId,proto3" json:"assistant_id,omitempty"`
+	// RequestID is the request to assign. If it is 0, the next request in the queue, or in lane if set, is assigned.
+	RequestId     uint64 `protobuf:"varint,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Lane          string `protobuf:"bytes,4,opt,name=lane,proto3" json:"lane,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignRequest) Reset() {
+	*x = AssignRequest{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignRequest) ProtoMessage() {}
+
+func (x *AssignRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignRequest.ProtoReflect.Descriptor instead.
+func (*AssignRequest) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AssignRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *AssignRequest) GetAssistantId() string {
+	if x != nil {
+		return x.AssistantId
+	}
+	return ""
+}
+
+func (x *AssignRequest) GetRequestId() uint64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *AssignRequest) GetLane() string {
+	if x != nil {
+		return x.Lane
+	}
+	return ""
+}
+
+type AssignResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Request is empty if the queue was empty, and the assistant is now waiting for the next request.
+	Request       *QueueRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignResponse) Reset() {
+	*x = AssignResponse{}
+	mi := &file_helpbot_v1_queue_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignResponse) ProtoMessage() {}
+
+func (x *AssignResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_helpbot_v1_queue_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignResponse.ProtoReflect.Descriptor instead.
+func (*AssignResponse) Descriptor() ([]byte, []int) {
+	return file_helpbot_v1_queue_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AssignResponse) GetRequest() *QueueRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+var File_helpbot_v1_queue_proto protoreflect.FileDescriptor
+
+const file_helpbot_v1_queue_proto_rawDesc = "" +
+	"\n" +
+	"\x16helpbot/v1/queue.proto\x12\n" +
+	"helpbot.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdc\x02\n" +
+	"\fQueueRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\x12!\n" +
+	"\fassistant_id\x18\x03 \x01(\tR\vassistantId\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"assignment\x18\x05 \x01(\tR\n" +
+	"assignment\x12\x14\n" +
+	"\x05group\x18\x06 \x01(\tR\x05group\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12 \n" +
+	"\vdescription\x18\b \x01(\tR\vdescription\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12;\n" +
+	"\vassigned_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"assignedAt\"-\n" +
+	"\x10ListQueueRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\"\xb7\x01\n" +
+	"\x11ListQueueResponse\x12\x1f\n" +
+	"\vqueue_state\x18\x01 \x01(\tR\n" +
+	"queueState\x122\n" +
+	"\awaiting\x18\x02 \x03(\v2\x18.helpbot.v1.QueueRequestR\awaiting\x124\n" +
+	"\bsessions\x18\x03 \x03(\v2\x18.helpbot.v1.QueueRequestR\bsessions\x12\x17\n" +
+	"\aon_duty\x18\x04 \x01(\x05R\x06onDuty\"N\n" +
+	"\x12GetPositionRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\"\x9b\x01\n" +
+	"\x13GetPositionResponse\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\x124\n" +
+	"\x16estimated_wait_seconds\x18\x02 \x01(\x03R\x14estimatedWaitSeconds\x122\n" +
+	"\arequest\x18\x03 \x01(\v2\x18.helpbot.v1.QueueRequestR\arequest\"\xca\x01\n" +
+	"\x0eEnqueueRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12#\n" +
+	"\rassignment_id\x18\x04 \x01(\x04R\fassignmentId\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x19\n" +
+	"\x05group\x18\x06 \x01(\bH\x00R\x05group\x88\x01\x01B\b\n" +
+	"\x06_group\"a\n" +
+	"\x0fEnqueueResponse\x122\n" +
+	"\arequest\x18\x01 \x01(\v2\x18.helpbot.v1.QueueRequestR\arequest\x12\x1a\n" +
+	"\bposition\x18\x02 \x01(\x05R\bposition\"I\n" +
+	"\rCancelRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1d\n" +
+	"\n" +
+	"student_id\x18\x02 \x01(\tR\tstudentId\"\x10\n" +
+	"\x0eCancelResponse\"\x80\x01\n" +
+	"\rAssignRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12!\n" +
+	"\fassistant_id\x18\x02 \x01(\tR\vassistantId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\x04R\trequestId\x12\x12\n" +
+	"\x04lane\x18\x04 \x01(\tR\x04lane\"D\n" +
+	"\x0eAssignResponse\x122\n" +
+	"\arequest\x18\x01 \x01(\v2\x18.helpbot.v1.QueueRequestR\arequest2\xee\x02\n" +
+	"\fQueueService\x12H\n" +
+	"\tListQueue\x12\x1c.helpbot.v1.ListQueueRequest\x1a\x1d.helpbot.v1.ListQueueResponse\x12N\n" +
+	"\vGetPosition\x12\x1e.helpbot.v1.GetPositionRequest\x1a\x1f.helpbot.v1.GetPositionResponse\x12B\n" +
+	"\aEnqueue\x12\x1a.helpbot.v1.EnqueueRequest\x1a\x1b.helpbot.v1.EnqueueResponse\x12?\n" +
+	"\x06Cancel\x12\x19.helpbot.v1.CancelRequest\x1a\x1a.helpbot.v1.CancelResponse\x12?\n" +
+	"\x06Assign\x12\x19.helpbot.v1.AssignRequest\x1a\x1a.helpbot.v1.AssignResponseB0Z.github.com/Raytar/helpbot/helpbot/v1;helpbotv1b\x06proto3"
+
+var (
+	file_helpbot_v1_queue_proto_rawDescOnce sync.Once
+	file_helpbot_v1_queue_proto_rawDescData []byte
+)
+
+func file_helpbot_v1_queue_proto_rawDescGZIP() []byte {
+	file_helpbot_v1_queue_proto_rawDescOnce.Do(func() {
+		file_helpbot_v1_queue_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_helpbot_v1_queue_proto_rawDesc), len(file_helpbot_v1_queue_proto_rawDesc)))
+	})
+	return file_helpbot_v1_queue_proto_rawDescData
+}
+
+var file_helpbot_v1_queue_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_helpbot_v1_queue_proto_goTypes = []any{
+	(*QueueRequest)(nil),          // 0: helpbot.v1.QueueRequest
+	(*ListQueueRequest)(nil),      // 1: helpbot.v1.ListQueueRequest
+	(*ListQueueResponse)(nil),     // 2: helpbot.v1.ListQueueResponse
+	(*GetPositionRequest)(nil),    // 3: helpbot.v1.GetPositionRequest
+	(*GetPositionResponse)(nil),   // 4: helpbot.v1.GetPositionResponse
+	(*EnqueueRequest)(nil),        // 5: helpbot.v1.EnqueueRequest
+	(*EnqueueResponse)(nil),       // 6: helpbot.v1.EnqueueResponse
+	(*CancelRequest)(nil),         // 7: helpbot.v1.CancelRequest
+	(*CancelResponse)(nil),        // 8: helpbot.v1.CancelResponse
+	(*AssignRequest)(nil),         // 9: helpbot.v1.AssignRequest
+	(*AssignResponse)(nil),        // 10: helpbot.v1.AssignResponse
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_helpbot_v1_queue_proto_depIdxs = []int32{
+	11, // 0: helpbot.v1.QueueRequest.created_at:type_name -> google.protobuf.Timestamp
+	11, // 1: helpbot.v1.QueueRequest.assigned_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: helpbot.v1.ListQueueResponse.waiting:type_name -> helpbot.v1.QueueRequest
+	0,  // 3: helpbot.v1.ListQueueResponse.sessions:type_name -> helpbot.v1.QueueRequest
+	0,  // 4: helpbot.v1.GetPositionResponse.request:type_name -> helpbot.v1.QueueRequest
+	0,  // 5: helpbot.v1.EnqueueResponse.request:type_name -> helpbot.v1.QueueRequest
+	0,  // 6: helpbot.v1.AssignResponse.request:type_name -> helpbot.v1.QueueRequest
+	1,  // 7: helpbot.v1.QueueService.ListQueue:input_type -> helpbot.v1.ListQueueRequest
+	3,  // 8: helpbot.v1.QueueService.GetPosition:input_type -> helpbot.v1.GetPositionRequest
+	5,  // 9: helpbot.v1.QueueService.Enqueue:input_type -> helpbot.v1.EnqueueRequest
+	7,  // 10: helpbot.v1.QueueService.Cancel:input_type -> helpbot.v1.CancelRequest
+	9,  // 11: helpbot.v1.QueueService.Assign:input_type -> helpbot.v1.AssignRequest
+	2,  // 12: helpbot.v1.QueueService.ListQueue:output_type -> helpbot.v1.ListQueueResponse
+	4,  // 13: helpbot.v1.QueueService.GetPosition:output_type -> helpbot.v1.GetPositionResponse
+	6,  // 14: helpbot.v1.QueueService.Enqueue:output_type -> helpbot.v1.EnqueueResponse
+	8,  // 15: helpbot.v1.QueueService.Cancel:output_type -> helpbot.v1.CancelResponse
+	10, // 16: helpbot.v1.QueueService.Assign:output_type -> helpbot.v1.AssignResponse
+	12, // [12:17] is the sub-list for method output_type
+	7,  // [7:12] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_helpbot_v1_queue_proto_init() }
+func file_helpbot_v1_queue_proto_init() {
+	if File_helpbot_v1_queue_proto != nil {
+		return
+	}
+	file_helpbot_v1_queue_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_helpbot_v1_queue_proto_rawDesc), len(file_helpbot_v1_queue_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_helpbot_v1_queue_proto_goTypes,
+		DependencyIndexes: file_helpbot_v1_queue_proto_depIdxs,
+		MessageInfos:      file_helpbot_v1_queue_proto_msgTypes,
+	}.Build()
+	File_helpbot_v1_queue_proto = out.File
+	file_helpbot_v1_queue_proto_goTypes = nil
+	file_helpbot_v1_queue_proto_depIdxs = nil
+}
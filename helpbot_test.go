@@ -1,10 +1,28 @@
 package helpbot
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"connectrpc.com/connect"
 	"github.com/Raytar/helpbot/database"
+	helpbotv1 "github.com/Raytar/helpbot/helpbot/v1"
+	"github.com/Raytar/helpbot/helpbot/v1/helpbotv1connect"
 	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+	qfpb "github.com/quickfeed/quickfeed/qf"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func TestCreateAndRetrieveHelpRequests(t *testing.T) {
@@ -45,10 +63,594 @@ func TestGetPosInQueue(t *testing.T) {
 	check("4", "1", 3)
 }
 
+func TestLabHours(t *testing.T) {
+	hours := []*models.LabHours{
+		{Weekday: time.Monday, Start: 10 * 60, End: 12 * 60},
+		{Weekday: time.Thursday, Start: 14*60 + 15, End: 16 * 60},
+	}
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		now    time.Time
+		active bool
+		next   time.Time
+		last   time.Time
+	}{
+		{at(1, 9, 59), false, at(1, 10, 0), at(-3, 16, 0)},
+		{at(1, 10, 0), true, at(4, 14, 15), at(-3, 16, 0)},
+		{at(1, 11, 59), true, at(4, 14, 15), at(-3, 16, 0)},
+		{at(1, 12, 0), false, at(4, 14, 15), at(1, 12, 0)},
+		{at(4, 15, 0), true, at(8, 10, 0), at(1, 12, 0)},
+		{at(7, 23, 0), false, at(8, 10, 0), at(4, 16, 0)},
+	}
+	for _, test := range tests {
+		if active := inLabHours(hours, test.now); active != test.active {
+			t.Errorf("inLabHours(%v) = %t, want %t", test.now, active, test.active)
+		}
+		if next, ok := nextLabHours(hours, test.now); !ok || !next.Equal(test.next) {
+			t.Errorf("nextLabHours(%v) = %v, want %v", test.now, next, test.next)
+		}
+		if last, ok := lastLabHoursEnd(hours, test.now); !ok || !last.Equal(test.last) {
+			t.Errorf("lastLabHoursEnd(%v) = %v, want %v", test.now, last, test.last)
+		}
+	}
+	if _, ok := nextLabHours(nil, at(1, 0, 0)); ok {
+		t.Error("nextLabHours(nil) returned ok")
+	}
+	if _, ok := lastLabHoursEnd(nil, at(1, 0, 0)); ok {
+		t.Error("lastLabHoursEnd(nil) returned ok")
+	}
+}
+
+func TestUpdateQueueStates(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+	bot := newTestBot(db)
+
+	if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "1"}); err != nil {
+		t.Fatalf("CreateCourse failed: %v", err)
+	}
+	now := time.Now().In(bot.location)
+	// the last lab hours ended two days ago
+	if err := db.CreateLabHours(&models.LabHours{GuildID: "1", Weekday: now.AddDate(0, 0, -2).Weekday(), Start: 0, End: 60}); err != nil {
+		t.Fatalf("CreateLabHours failed: %v", err)
+	}
+	if _, err := db.StartShift("1", "ta"); err != nil {
+		t.Fatalf("StartShift failed: %v", err)
+	}
+
+	// the bot restarts outside the lab hours, and the assistant went on duty after they ended
+	bot.updateQueueStates(now)
+	if shifts, err := db.GetOnDuty("1"); err != nil || len(shifts) != 1 {
+		t.Errorf("GetOnDuty() = %v, %v, want the assistant on duty", shifts, err)
+	}
+	// the queue that was opened manually is not closed at the restart
+	if course, err := db.GetCourse(&models.Course{GuildID: "1"}); err != nil || course.QueueState != models.QueueOpen {
+		t.Errorf("GetCourse() = %+v, %v, want open queue", course, err)
+	}
+
+	// the queue is closed when the lab hours end while the bot is running
+	bot.labHoursActive["1"] = true
+	bot.updateQueueStates(now)
+	if course, err := db.GetCourse(&models.Course{GuildID: "1"}); err != nil || course.QueueState != models.QueueClosed {
+		t.Errorf("GetCourse() = %+v, %v, want closed queue", course, err)
+	}
+}
+
+func TestEstimateWait(t *testing.T) {
+	ahead := []*models.HelpRequest{{Type: "help"}, {Type: "approve"}, {Type: "approve"}, {Type: "other"}}
+	durations := map[string]time.Duration{"help": 20 * time.Minute}
+
+	// approve and other requests use the default durations of 5 and 10 minutes
+	if got, want := estimateWait(ahead, durations, 1), 40*time.Minute; got != want {
+		t.Errorf("estimateWait(1 assistant) = %v, want %v", got, want)
+	}
+	if got, want := estimateWait(ahead, durations, 2), 20*time.Minute; got != want {
+		t.Errorf("estimateWait(2 assistants) = %v, want %v", got, want)
+	}
+	if got, want := estimateWait(ahead, durations, 0), 40*time.Minute; got != want {
+		t.Errorf("estimateWait(0 assistants) = %v, want %v", got, want)
+	}
+	if got := estimateWait(nil, durations, 1); got != 0 {
+		t.Errorf("estimateWait(empty queue) = %v, want 0", got)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	loc := time.UTC
+	// Thursday
+	now := time.Date(2024, 3, 14, 15, 30, 0, 0, loc)
+	tests := []struct {
+		period string
+		want   time.Time
+	}{
+		{"today", time.Date(2024, 3, 14, 0, 0, 0, 0, loc)},
+		{"week", time.Date(2024, 3, 11, 0, 0, 0, 0, loc)},
+		{"course", time.Time{}},
+	}
+	for _, test := range tests {
+		if got := periodStart(test.period, now, loc); !got.Equal(test.want) {
+			t.Errorf("periodStart(%s) = %v, want %v", test.period, got, test.want)
+		}
+	}
+	// Sunday belongs to the week that started on Monday
+	if got, want := periodStart("week", time.Date(2024, 3, 17, 12, 0, 0, 0, loc), loc), time.Date(2024, 3, 11, 0, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("periodStart(week) = %v, want %v", got, want)
+	}
+}
+
+func TestExportRequests(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	day := time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+	for i, studentID := range []string{"student1", "student2", "student3"} {
+		req := &models.HelpRequest{StudentUserID: studentID, GuildID: "1", Type: "help", Done: true, Status: models.StatusResolved, AssistantUserID: "ta"}
+		req.CreatedAt = day.AddDate(0, 0, i)
+		if err := db.CreateHelpRequest(req); err != nil {
+			t.Fatalf("CreateHelpRequest failed: %v", err)
+		}
+	}
+	start, end, err := ParseDateRange("2024-03-15", "2024-03-16", time.UTC)
+	if err != nil {
+		t.Fatalf("ParseDateRange failed: %v", err)
+	}
+	requests, err := db.GetHelpRequests("1", start, end)
+	if err != nil || len(requests) != 2 {
+		t.Fatalf("GetHelpRequests() = %d requests, %v, want 2", len(requests), err)
+	}
+
+	key := []byte("secret")
+	var buf bytes.Buffer
+	if err := ExportRequests(&buf, requests, "csv", key); err != nil {
+		t.Fatalf("ExportRequests(csv) failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("ExportRequests(csv) = %v, %v, want header and 2 rows", rows, err)
+	}
+	if got := strings.Join(rows[1], ","); strings.Contains(got, "student2") || !strings.Contains(got, Pseudonym(key, "student2")) || !strings.Contains(got, "ta") {
+		t.Errorf("ExportRequests(csv) row = %q, want pseudonymised student and assistant", got)
+	}
+
+	buf.Reset()
+	if err := ExportRequests(&buf, requests, "json", key); err != nil {
+		t.Fatalf("ExportRequests(json) failed: %v", err)
+	}
+	var records []*ExportRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil || len(records) != 2 {
+		t.Fatalf("ExportRequests(json) = %s, %v, want 2 records", buf.String(), err)
+	}
+	if records[1].Student != Pseudonym(key, "student3") || records[1].CreatedAt != "2024-03-16T12:00:00Z" {
+		t.Errorf("ExportRequests(json) record = %+v", records[1])
+	}
+
+	if err := ExportRequests(&buf, requests, "csv", nil); err == nil {
+		t.Error("ExportRequests succeeded without a key")
+	}
+
+	// the range is in the bot's timezone, while the requests are stored in UTC
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	late := &models.HelpRequest{StudentUserID: "student4", GuildID: "1", Type: "help", Done: true, Status: models.StatusResolved, AssistantUserID: "ta"}
+	// 2024-03-14 21:00 in the bot's timezone
+	late.CreatedAt = time.Date(2024, 3, 15, 2, 0, 0, 0, time.UTC)
+	if err := db.CreateHelpRequest(late); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if start, end, err = ParseDateRange("2024-03-14", "2024-03-14", loc); err != nil {
+		t.Fatalf("ParseDateRange failed: %v", err)
+	}
+	requests, err = db.GetHelpRequests("1", start, end)
+	if err != nil || len(requests) != 2 || requests[0].StudentUserID != "student1" || requests[1].StudentUserID != "student4" {
+		t.Errorf("GetHelpRequests() = %v, %v, want student1 and student4", requests, err)
+	}
+
+	if _, _, err := ParseDateRange("2024-03-15", "2024-03-14", time.UTC); err == nil {
+		t.Error("ParseDateRange succeeded for a range that ends before it starts")
+	}
+}
+
+func TestQueueAPI(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "1"}); err != nil {
+		t.Fatalf("CreateCourse failed: %v", err)
+	}
+	if err := db.CreateStudent(&models.Student{UserID: "s1", GuildID: "1"}); err != nil {
+		t.Fatalf("CreateStudent failed: %v", err)
+	}
+	bot := newTestBot(db)
+	srv := httptest.NewServer(bot.httpHandler())
+	defer srv.Close()
+
+	withToken := func(token string) connect.ClientOption {
+		return connect.WithInterceptors(connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+			return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+				req.Header().Set("Authorization", "Bearer "+token)
+				return next(ctx, req)
+			}
+		}))
+	}
+	ctx := context.Background()
+	client := helpbotv1connect.NewQueueServiceClient(srv.Client(), srv.URL, withToken("secret"))
+
+	unauthorized := helpbotv1connect.NewQueueServiceClient(srv.Client(), srv.URL, withToken("wrong"))
+	if _, err := unauthorized.ListQueue(ctx, connect.NewRequest(&helpbotv1.ListQueueRequest{GuildId: "1"})); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Errorf("ListQueue with wrong token: got %v, want unauthenticated", err)
+	}
+
+	if _, err := client.Enqueue(ctx, connect.NewRequest(&helpbotv1.EnqueueRequest{GuildId: "1", StudentId: "s2", Type: "help"})); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Enqueue for unregistered student: got %v, want failed precondition", err)
+	}
+	// requests made through the API are checked like the slash commands
+	bot.setAssignments("1", []*qfpb.Assignment{{ID: 1, Name: "lab1"}})
+	if _, err := client.Enqueue(ctx, connect.NewRequest(&helpbotv1.EnqueueRequest{GuildId: "1", StudentId: "s1", Type: "approve"})); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Enqueue approval without assignment: got %v, want failed precondition", err)
+	}
+	if _, err := client.Enqueue(ctx, connect.NewRequest(&helpbotv1.EnqueueRequest{GuildId: "1", StudentId: "s1", Type: "help", AssignmentId: 2})); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Enqueue for unknown assignment: got %v, want failed precondition", err)
+	}
+	resp, err := client.Enqueue(ctx, connect.NewRequest(&helpbotv1.EnqueueRequest{GuildId: "1", StudentId: "s1", Type: "help", AssignmentId: 1, Description: "segfault"}))
+	if err != nil || resp.Msg.GetPosition() != 1 || resp.Msg.GetRequest().GetAssignment() != "lab1" {
+		t.Fatalf("Enqueue() = %+v, %v, want position 1 for lab1", resp, err)
+	}
+
+	// the generated client uses the binary protobuf encoding by default; JSON clients are also supported
+	jsonClient := helpbotv1connect.NewQueueServiceClient(srv.Client(), srv.URL, connect.WithProtoJSON(), withToken("secret"))
+	queue, err := jsonClient.ListQueue(ctx, connect.NewRequest(&helpbotv1.ListQueueRequest{GuildId: "1"}))
+	if err != nil || len(queue.Msg.GetWaiting()) != 1 || queue.Msg.GetWaiting()[0].GetDescription() != "segfault" || queue.Msg.GetQueueState() != "open" {
+		t.Fatalf("ListQueue() = %+v, %v, want one waiting request", queue, err)
+	}
+
+	requestID := queue.Msg.GetWaiting()[0].GetId()
+	// requests can only be assigned to the guild's assistants
+	addTestMember(t, bot, "1", "s1")
+	for _, assistantID := range []string{"s1", "unknown"} {
+		if _, err := client.Assign(ctx, connect.NewRequest(&helpbotv1.AssignRequest{GuildId: "1", AssistantId: assistantID, RequestId: requestID})); connect.CodeOf(err) != connect.CodePermissionDenied {
+			t.Errorf("Assign() to %s: got %v, want permission denied", assistantID, err)
+		}
+	}
+	addTestMember(t, bot, "1", "ta", RoleAssistant)
+	addTestMember(t, bot, "1", "ta2", RoleAssistant)
+	assigned, err := client.Assign(ctx, connect.NewRequest(&helpbotv1.AssignRequest{GuildId: "1", AssistantId: "ta", RequestId: requestID}))
+	if err != nil || assigned.Msg.GetRequest().GetStudentId() != "s1" || assigned.Msg.GetRequest().GetAssistantId() != "ta" {
+		t.Fatalf("Assign() = %+v, %v, want s1 assigned to ta", assigned, err)
+	}
+	if _, err := client.Assign(ctx, connect.NewRequest(&helpbotv1.AssignRequest{GuildId: "1", AssistantId: "ta2", RequestId: requestID})); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Assign() of taken request: got %v, want failed precondition", err)
+	}
+
+	// plain JSON clients can use the API without a Connect client
+	listQueue := srv.URL + helpbotv1connect.QueueServiceListQueueProcedure
+	req, _ := http.NewRequest(http.MethodPost, listQueue, strings.NewReader(`{"guildId": "1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+	httpResp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", listQueue, err)
+	}
+	defer httpResp.Body.Close()
+	data, _ := io.ReadAll(httpResp.Body)
+	var body helpbotv1.ListQueueResponse
+	if err := protojson.Unmarshal(data, &body); err != nil || httpResp.StatusCode != http.StatusOK || len(body.GetSessions()) != 1 {
+		t.Errorf("POST %s = %d %s, %v, want one session", listQueue, httpResp.StatusCode, data, err)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "1", Name: "DAT320"}); err != nil {
+		t.Fatalf("CreateCourse failed: %v", err)
+	}
+	bot := newTestBot(db)
+	srv := httptest.NewServer(bot.httpHandler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/dashboard/1")
+	if err != nil {
+		t.Fatalf("GET /dashboard/1 failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /dashboard/1 without token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	// the API token gives write access to the queue, and is not accepted in the URL
+	resp, err = srv.Client().Get(srv.URL + "/dashboard/1?token=secret")
+	if err != nil {
+		t.Fatalf("GET /dashboard/1 failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /dashboard/1 with API token = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	resp, err = srv.Client().Get(srv.URL + "/dashboard/1?token=board")
+	if err != nil {
+		t.Fatalf("GET /dashboard/1 failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET /dashboard/1 = %d %s, want HTML", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/dashboard/1/events?token=board", nil)
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /dashboard/1/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	next := func() *dashboardState {
+		t.Helper()
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				if strings.Contains(data, "private") {
+					t.Errorf("event %s includes the description", data)
+				}
+				var state dashboardState
+				if err := json.Unmarshal([]byte(data), &state); err != nil {
+					t.Fatalf("failed to decode event: %v", err)
+				}
+				return &state
+			}
+		}
+	}
+
+	if state := next(); state.Course != "DAT320" || state.QueueState != "open" || len(state.Waiting) != 0 {
+		t.Errorf("first event = %+v, want an empty open queue", state)
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "s1", GuildID: "1", Type: "help", Description: "private"}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	bot.queueChanged("1")
+	if state := next(); len(state.Waiting) != 1 || state.Waiting[0].StudentID != "s1" || state.Names["s1"] != "s1" {
+		t.Errorf("event after queue change = %+v, want s1 waiting", state)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "metrics"}); err != nil {
+		t.Fatalf("CreateCourse failed: %v", err)
+	}
+	bot := newTestBot(db)
+	srv := httptest.NewServer(bot.httpHandler())
+	defer srv.Close()
+
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "s1", GuildID: "metrics", Type: "help"}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "s2", GuildID: "metrics", Type: "help"}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	// the wait time is recorded by the bot once the assignment is committed
+	addTestMember(t, bot, "metrics", "ta", RoleAssistant)
+	if _, err := (&queueService{bot}).Assign(context.Background(), connect.NewRequest(&helpbotv1.AssignRequest{GuildId: "metrics", AssistantId: "ta"})); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	observeCommand("next", 10*time.Millisecond)
+
+	scrape := func(token string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET /metrics failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body bytes.Buffer
+		_, _ = body.ReadFrom(resp.Body)
+		return resp.StatusCode, body.String()
+	}
+	for _, token := range []string{"wrong", "secret"} {
+		if code, _ := scrape(token); code != http.StatusUnauthorized {
+			t.Errorf("GET /metrics with token %q = %d, want %d", token, code, http.StatusUnauthorized)
+		}
+	}
+	code, body := scrape("metrics")
+	if code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want %d", code, http.StatusOK)
+	}
+	for _, want := range []string{
+		`helpbot_queue_length{guild="metrics",lane="help"} 1`,
+		`helpbot_queue_length{guild="metrics",lane="approve"} 0`,
+		`helpbot_wait_time_seconds_count{guild="metrics",type="help"} 1`,
+		`helpbot_commands_total{command="next"}`,
+		`helpbot_command_duration_seconds_count{command="next"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /metrics is missing %s", want)
+		}
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := setupTestDatabase(t)
+	defer db.Close()
+
+	if err := db.CreateStudent(&models.Student{UserID: "s1", GuildID: "1"}); err != nil {
+		t.Fatalf("CreateStudent failed: %v", err)
+	}
+	req := &models.HelpRequest{StudentUserID: "s1", GuildID: "1", Type: "help"}
+	if err := db.CreateHelpRequest(req); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if err := db.CreateHelpRequest(&models.HelpRequest{StudentUserID: "s2", GuildID: "1", Type: "help"}); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if _, _, err := db.AssignRequest("ta", "1", req.ID); err != nil {
+		t.Fatalf("AssignRequest failed: %v", err)
+	}
+	if _, err := db.CloseSession("ta", "1", models.StatusResolved); err != nil {
+		t.Fatalf("CloseSession failed: %v", err)
+	}
+
+	bot := newTestBot(db)
+	m := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "s1"}},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "lane", Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+			Name:    "set",
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "priority", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(1)}},
+		}}},
+	}}
+	pendingReplies.Store(m.ID, &commandReply{})
+	recordReply(m, "Lane help has priority 1.", nil)
+	bot.recordCommand(m)
+
+	entries, err := db.GetAuditEntries("1", "s1", 10)
+	if err != nil {
+		t.Fatalf("GetAuditEntries failed: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s %s %s", entry.Kind, entry.Action, entry.Target))
+	}
+	requestTarget := fmt.Sprintf("help_requests/%d", req.ID)
+	want := []string{
+		"command lane ",
+		"change update " + requestTarget,
+		"change update " + requestTarget,
+		"change create " + requestTarget,
+		"change create students/1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("GetAuditEntries() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if cmd := entries[0]; cmd.Details != "set priority=1" || cmd.Outcome != "Lane help has priority 1." || cmd.Error != "" {
+		t.Errorf("command entry = %+v, want the options and the reply", cmd)
+	}
+	// changes record the columns that were set, but not their values
+	if closed := entries[1]; !strings.Contains(closed.Details, "status") || strings.Contains(closed.Details, "resolved") {
+		t.Errorf("change entry details = %q, want the columns set by closing the session", closed.Details)
+	}
+	if created := entries[3]; !strings.Contains(created.Details, "student_user_id") || strings.Contains(created.Details, "s1") {
+		t.Errorf("change entry details = %q, want the columns of the created request", created.Details)
+	}
+	if _, ok := pendingReplies.Load(m.ID); ok {
+		t.Error("recordCommand did not remove the pending reply")
+	}
+
+	// changes to a group's request are listed for every member of the group
+	groupReq := &models.HelpRequest{StudentUserID: "s3", GuildID: "1", Type: "help", GroupID: 1, GroupMemberIDs: []string{"s4"}}
+	if err := db.CreateHelpRequest(groupReq); err != nil {
+		t.Fatalf("CreateHelpRequest failed: %v", err)
+	}
+	if err := db.CancelHelpRequest("1", "s4"); err != nil {
+		t.Fatalf("CancelHelpRequest failed: %v", err)
+	}
+	groupTarget := fmt.Sprintf("help_requests/%d", groupReq.ID)
+	for _, userID := range []string{"s3", "s4"} {
+		entries, err := db.GetAuditEntries("1", userID, 10)
+		if err != nil || len(entries) != 2 || entries[0].Target != groupTarget || entries[1].Target != groupTarget {
+			t.Errorf("GetAuditEntries(%s) = %v, %v, want the changes to %s", userID, entries, err, groupTarget)
+		}
+	}
+
+	// changes are recorded with the user who made them, and components are recorded like commands
+	if err := db.CreateCourse(&models.Course{CourseID: 1, GuildID: "1"}); err != nil {
+		t.Fatalf("CreateCourse failed: %v", err)
+	}
+	if err := db.WithActor("ta").SetQueueState("1", models.QueueClosed); err != nil {
+		t.Fatalf("SetQueueState failed: %v", err)
+	}
+	claim := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "component",
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "ta"}},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: componentClaim, Values: []string{"2"}},
+	}}
+	bot.routeCustomID(customIDMap{componentClaim: func(m *discordgo.InteractionCreate) {
+		recordReply(m, "You are now helping s2.", nil)
+	}}, componentClaim, claim)
+
+	entries, err = db.GetAuditEntries("1", "ta", 10)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetAuditEntries(ta) = %v, %v, want 2 entries", entries, err)
+	}
+	if got := entries[0]; got.Kind != models.AuditInteraction || got.Action != componentClaim || got.Details != "selected=2" || got.Outcome != "You are now helping s2." {
+		t.Errorf("interaction entry = %+v, want the selected request and the reply", got)
+	}
+	if got := entries[1]; got.Kind != models.AuditChange || got.Target != "courses/1" || got.ActorID != "ta" || got.Details != "queue_state" {
+		t.Errorf("change entry = %+v, want the course change made by ta", got)
+	}
+	if line := formatAuditEntry(entries[1]); !strings.Contains(line, "update courses/1 by <@ta>: `queue_state`") {
+		t.Errorf("formatAuditEntry() = %q, want the actor and the changed column", line)
+	}
+
+	// entries are kept for the retention period
+	bot.pruneAuditLog(time.Now())
+	if entries, _ := db.GetAuditEntries("1", "s1", 10); len(entries) != len(want) {
+		t.Errorf("pruneAuditLog deleted recent entries: got %d entries, want %d", len(entries), len(want))
+	}
+	if _, err := db.DeleteAuditEntriesBefore(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("DeleteAuditEntriesBefore failed: %v", err)
+	}
+	if entries, _ := db.GetAuditEntries("1", "s1", 10); len(entries) != 0 {
+		t.Errorf("DeleteAuditEntriesBefore left %d entries", len(entries))
+	}
+}
+
 func setupTestDatabase(t *testing.T) *database.Database {
-	db, err := database.OpenDatabase("file::memory:?cache=shared", nil)
+	db, err := database.OpenDatabase("file::memory:?cache=shared", logrus.New())
 	if err != nil {
 		t.Fatalf("Failed to open database: %v", err)
 	}
 	return db
 }
+
+// newTestBot returns a bot that uses the database, without connections to Discord or QuickFeed.
+func newTestBot(db *database.Database) *HelpBot {
+	log := logrus.New()
+	// The client is not connected, and only its empty state is used
+	client, _ := discordgo.New("Bot test")
+	return &HelpBot{
+		cfg:            Config{APIToken: "secret", DashboardToken: "board", MetricsToken: "metrics"},
+		client:         client,
+		db:             db,
+		log:            log,
+		dm:             newDMSender(client, log),
+		roles:          make(map[string]map[string]string),
+		location:       time.Local,
+		assignments:    make(map[string][]*qfpb.Assignment),
+		labHoursActive: make(map[string]bool),
+	}
+}
+
+// addTestMember adds a member with the given roles to the bot's cache of the guild.
+func addTestMember(t *testing.T, bot *HelpBot, guildID, userID string, roles ...string) {
+	t.Helper()
+	if _, err := bot.client.State.Guild(guildID); err != nil {
+		if err := bot.client.State.GuildAdd(&discordgo.Guild{ID: guildID}); err != nil {
+			t.Fatalf("GuildAdd failed: %v", err)
+		}
+	}
+	if bot.roles[guildID] == nil {
+		bot.roles[guildID] = make(map[string]string)
+	}
+	// The roles' names are used as their IDs
+	for _, role := range roles {
+		bot.roles[guildID][role] = role
+	}
+	if err := bot.client.State.MemberAdd(&discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}, Roles: roles}); err != nil {
+		t.Fatalf("MemberAdd failed: %v", err)
+	}
+}
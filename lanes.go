@@ -0,0 +1,113 @@
+package helpbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// requestTypes are the types of requests, each of which is waiting in its own lane of the queue.
+var requestTypes = []string{"help", "approve"}
+
+// minWeight is the minimum weight of a lane.
+var minWeight = 1.0
+
+// laneChoices returns the choices of a command option for selecting a lane.
+func laneChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(requestTypes))
+	for i, name := range requestTypes {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name}
+	}
+	return choices
+}
+
+// laneCounts returns the number of waiting requests in the guild, and a breakdown of the number by lane,
+// with the lanes in order of priority.
+func (bot *HelpBot) laneCounts(guildID string) (int, string, error) {
+	counts, err := bot.db.CountWaitingByLane(guildID)
+	if err != nil {
+		return 0, "", err
+	}
+	lanes, err := bot.db.GetLanes(guildID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, lane := range lanes {
+		names = append(names, lane.Name)
+		seen[lane.Name] = true
+	}
+	for _, name := range requestTypes {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+
+	total := 0
+	breakdown := make([]string, len(names))
+	for i, name := range names {
+		total += counts[name]
+		breakdown[i] = fmt.Sprintf("%s: %d", name, counts[name])
+	}
+	return total, strings.Join(breakdown, ", "), nil
+}
+
+func (bot *HelpBot) laneCommand(m *discordgo.InteractionCreate) {
+	options := m.ApplicationCommandData().Options
+	if len(options) == 0 {
+		replyMsg(bot.client, m, "You must specify a subcommand.")
+		return
+	}
+	subcommand := options[0]
+	args := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subcommand.Options {
+		args[opt.Name] = opt
+	}
+
+	switch subcommand.Name {
+	case "set":
+		lane := &models.Lane{
+			GuildID:  m.GuildID,
+			Name:     args["name"].StringValue(),
+			Priority: int(args["priority"].IntValue()),
+			Weight:   1,
+		}
+		if opt, ok := args["weight"]; ok {
+			lane.Weight = int(opt.IntValue())
+		}
+		if err := bot.actorDB(m).SetLane(lane); err != nil {
+			replyMsg(bot.client, m, fmt.Sprintf("Failed to set lane: %v", err))
+			return
+		}
+		replyMsg(bot.client, m, fmt.Sprintf("The '%s' lane now has priority %d and weight %d.", lane.Name, lane.Priority, lane.Weight))
+
+	case "list":
+		lanes, err := bot.db.GetLanes(m.GuildID)
+		if err != nil {
+			replyMsg(bot.client, m, "Failed to get lanes.")
+			return
+		}
+		if len(lanes) == 0 {
+			replyMsg(bot.client, m, "There are no lanes configured. All requests are taken in the order they were made.")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("Lanes, in order of priority:\n\n")
+		for _, lane := range lanes {
+			fmt.Fprintf(&sb, "%s: priority %d, weight %d\n", lane.Name, lane.Priority, lane.Weight)
+		}
+		sb.WriteString("\nOther request types have priority 0 and weight 1.")
+		replyMsg(bot.client, m, sb.String())
+
+	case "remove":
+		if err := bot.actorDB(m).DeleteLane(m.GuildID, args["name"].StringValue()); err != nil {
+			replyMsg(bot.client, m, fmt.Sprintf("Failed to remove lane: %v", err))
+			return
+		}
+		replyMsg(bot.client, m, "The lane was removed. Its requests have priority 0 and weight 1.")
+	}
+}
@@ -0,0 +1,57 @@
+package helpbot
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sirupsen/logrus"
+)
+
+// dmInterval is the minimum time between direct messages sent by the dmSender.
+const dmInterval = 500 * time.Millisecond
+
+type directMessage struct {
+	userID string
+	msg    string
+}
+
+// dmSender sends direct messages from a queue at a limited rate, so that notifying
+// many students at once, e.g., when the queue is cleared, does not hit Discord's rate limits.
+type dmSender struct {
+	s     *discordgo.Session
+	log   *logrus.Logger
+	queue chan directMessage
+}
+
+func newDMSender(s *discordgo.Session, log *logrus.Logger) *dmSender {
+	return &dmSender{s: s, log: log, queue: make(chan directMessage, 1000)}
+}
+
+// send queues a direct message to the user with the given ID.
+func (d *dmSender) send(userID, msg string) {
+	select {
+	case d.queue <- directMessage{userID: userID, msg: msg}:
+	default:
+		d.log.Errorf("Direct message queue is full, dropping message to %s", userID)
+	}
+}
+
+// run sends the queued messages until the context is canceled.
+func (d *dmSender) run(ctx context.Context) {
+	ticker := time.NewTicker(dmInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case dm := <-d.queue:
+			sendMsg(d.s, &discordgo.User{ID: dm.userID}, dm.msg)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
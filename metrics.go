@@ -0,0 +1,141 @@
+package helpbot
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/Raytar/helpbot/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	queueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "helpbot",
+		Name:      "queue_length",
+		Help:      "Number of help requests waiting in the queue.",
+	}, []string{"guild", "lane"})
+
+	commandsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "helpbot",
+		Name:      "commands_total",
+		Help:      "Number of slash commands handled.",
+	}, []string{"command"})
+
+	commandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "helpbot",
+		Name:      "command_duration_seconds",
+		Help:      "Time spent handling a slash command.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"command"})
+
+	quickFeedErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "helpbot",
+		Name:      "quickfeed_errors_total",
+		Help:      "Number of failed RPCs to QuickFeed.",
+	}, []string{"procedure", "code"})
+
+	discordErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "helpbot",
+		Name:      "discord_errors_total",
+		Help:      "Number of failed requests to the Discord API when replying to interactions and sending direct messages.",
+	}, []string{"operation"})
+
+	waitTime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "helpbot",
+		Name:      "wait_time_seconds",
+		Help:      "Time from a help request is created until it is assigned to a teaching assistant.",
+		Buckets:   durationBuckets,
+	}, []string{"guild", "type"})
+
+	sessionTime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "helpbot",
+		Name:      "session_time_seconds",
+		Help:      "Time from a help request is assigned to a teaching assistant until the session is closed.",
+		Buckets:   durationBuckets,
+	}, []string{"guild", "type"})
+)
+
+// durationBuckets range from 30 seconds to about 4 hours.
+var durationBuckets = prometheus.ExponentialBuckets(30, 2, 10)
+
+// Operations counted by discordErrors.
+const (
+	opRespond       = "respond"
+	opCreateChannel = "create_channel"
+	opSendMessage   = "send_message"
+)
+
+// initCommandMetrics exports the metrics of every command, such that commands that have not been used are reported as zero.
+func (bot *HelpBot) initCommandMetrics() {
+	for name := range bot.commands {
+		commandsTotal.WithLabelValues(name)
+		commandDuration.WithLabelValues(name)
+	}
+}
+
+// observeCommand records that the command was handled in the given time.
+func observeCommand(name string, elapsed time.Duration) {
+	commandsTotal.WithLabelValues(name).Inc()
+	commandDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+}
+
+// observeWaitTime records how long the request waited in the queue, if it was assigned to an assistant.
+// It must be called after the assignment is committed, such that rolled back assignments are not recorded.
+func observeWaitTime(req *models.HelpRequest) {
+	if req == nil || req.AssignedAt.IsZero() || req.CreatedAt.IsZero() {
+		return
+	}
+	waitTime.WithLabelValues(req.GuildID, req.Type).Observe(req.AssignedAt.Sub(req.CreatedAt).Seconds())
+}
+
+// observeSessionTime records how long the closed session lasted, if any.
+func observeSessionTime(req *models.HelpRequest) {
+	if req == nil || req.DoneAt.IsZero() {
+		return
+	}
+	sessionTime.WithLabelValues(req.GuildID, req.Type).Observe(req.DoneAt.Sub(req.AssignedAt).Seconds())
+}
+
+// updateQueueLength sets the queue length metrics of the guild's lanes.
+func (bot *HelpBot) updateQueueLength(guildID string) {
+	counts, err := bot.db.CountWaitingByLane(guildID)
+	if err != nil {
+		return
+	}
+	for _, lane := range requestTypes {
+		queueLength.WithLabelValues(guildID, lane).Set(float64(counts[lane]))
+	}
+}
+
+// metricsClientInterceptor counts the failed RPCs of a Connect client.
+func metricsClientInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			resp, err := next(ctx, req)
+			if err != nil {
+				quickFeedErrors.WithLabelValues(req.Spec().Procedure, connect.CodeOf(err).String()).Inc()
+			}
+			return resp, err
+		}
+	}
+}
+
+// handleMetrics registers the Prometheus metrics handler. Scrapers must send the metrics token,
+// which is separate from the API token, since scrapers only need to read the metrics.
+func (bot *HelpBot) handleMetrics(mux *http.ServeMux) {
+	if bot.cfg.MetricsToken == "" {
+		return
+	}
+	metrics := promhttp.Handler()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		if !validToken(r.Header.Get("Authorization"), bot.cfg.MetricsToken) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
@@ -6,6 +6,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// RequestStatus is the state of a help request. A request starts out as waiting, is in progress while
+// a teaching assistant is helping the student, and ends up as resolved, no-show or cancelled.
+type RequestStatus string
+
+const (
+	StatusWaiting    RequestStatus = "waiting"
+	StatusInProgress RequestStatus = "in_progress"
+	StatusResolved   RequestStatus = "resolved"
+	StatusNoShow     RequestStatus = "no_show"
+	StatusCancelled  RequestStatus = "cancelled"
+)
+
 type HelpRequest struct {
 	gorm.Model
 	StudentUserID   string `gorm:"index"`
@@ -14,16 +26,50 @@ type HelpRequest struct {
 	Assistant       Assistant
 	GuildID         string `gorm:"index"`
 	Type            string `gorm:"index"`
-	Done            bool
-	Reason          string
-	DoneAt          time.Time
+	AssignmentID    uint64 `gorm:"index"`
+	AssignmentName  string
+	// GroupID is the QuickFeed group that the request was made on behalf of, if any.
+	GroupID   uint64 `gorm:"index"`
+	GroupName string
+	// GroupMemberIDs are the Discord user IDs of the group's registered members.
+	GroupMemberIDs []string      `gorm:"serializer:json"`
+	Status         RequestStatus `gorm:"index;default:waiting"`
+	// Description and CodeLocation are provided by the student when requesting help.
+	Description  string
+	CodeLocation string
+	// Done is true when the request is closed, i.e., resolved, no-show or cancelled.
+	Done       bool
+	Reason     string
+	AssignedAt time.Time
+	DoneAt     time.Time
+	// SubmissionID and SubmissionStatus record the latest QuickFeed submission status set by the assistant during the session.
+	// Every status that is set is also recorded as a Grade.
+	SubmissionID        uint64
+	SubmissionStatus    string
+	SubmissionUpdatedAt time.Time
+	// ThreadID is the private thread of the request's session.
+	ThreadID string
+}
+
+// Grade records a QuickFeed submission status set by an assistant during a help request's session.
+type Grade struct {
+	gorm.Model
+	GuildID         string `gorm:"index"`
+	HelpRequestID   uint   `gorm:"index"`
+	AssistantUserID string
+	SubmissionID    uint64
+	Status          string
 }
 
 type Assistant struct {
 	gorm.Model
-	UserID      string `gorm:"primary_key"`
-	GuildID     string `gorm:"primary_key"`
-	Waiting     bool
+	UserID  string `gorm:"primary_key"`
+	GuildID string `gorm:"primary_key"`
+	Waiting bool
+	// WaitingSince is the time the assistant started waiting for a new request.
+	WaitingSince time.Time
+	// WaitingLane is the lane the assistant is waiting for a request from. Empty means any lane.
+	WaitingLane string `gorm:"not null;default:''"`
 	LastRequest time.Time
 }
 
@@ -35,11 +81,116 @@ type Student struct {
 	GithubLogin string
 	Name        string
 	StudentID   string
+	// QuickFeedID is the student's user ID on QuickFeed.
+	QuickFeedID uint64
 }
 
+// QueueState is the state of a course's help queue.
+type QueueState string
+
+const (
+	// QueueOpen accepts new requests.
+	QueueOpen QueueState = "open"
+	// QueueClosed refuses new requests. A closed queue is opened automatically when lab hours start.
+	QueueClosed QueueState = "closed"
+	// QueueFrozen refuses new requests, and is not opened or closed automatically by the lab hours.
+	QueueFrozen QueueState = "frozen"
+)
+
+// AssignmentStrategy determines which of the waiting assistants is assigned a new request.
+type AssignmentStrategy string
+
+const (
+	// StrategyFIFO assigns the request to the assistant that has waited the longest.
+	StrategyFIFO AssignmentStrategy = "fifo"
+	// StrategyRoundRobin assigns the request to the assistant that was least recently assigned a request.
+	StrategyRoundRobin AssignmentStrategy = "round-robin"
+	// StrategyLeastLoaded assigns the request to the assistant that has had the fewest sessions today.
+	StrategyLeastLoaded AssignmentStrategy = "least-loaded"
+)
+
 type Course struct {
 	CourseID int64 `gorm:"primary_key"`
 	Name     string
 	GuildID  string
 	Year     uint32
+	// IgnoreScoreLimit allows approval requests for submissions below the assignment's score limit.
+	IgnoreScoreLimit bool
+	QueueState       QueueState `gorm:"default:open"`
+	// SessionChannelID is the channel in which private threads are created for help sessions.
+	SessionChannelID string
+	// BoardChannelID and BoardMessageID identify the queue board message that is updated whenever the queue changes.
+	BoardChannelID string
+	BoardMessageID string
+	// AssignmentStrategy determines which waiting assistant is assigned a new request.
+	AssignmentStrategy AssignmentStrategy `gorm:"default:fifo"`
+}
+
+// LabHours is a weekly time slot during which the queue of the guild's course is open.
+type LabHours struct {
+	gorm.Model
+	GuildID string `gorm:"index"`
+	Weekday time.Weekday
+	// Start and End are given in minutes after midnight.
+	Start int
+	End   int
+}
+
+// Lane configures how the requests of one type, e.g., "help" or "approve", are prioritised in a guild's queue.
+// Requests are taken from the lanes with the highest priority that have waiting requests. Among those lanes,
+// the request with the highest weighted age, i.e., the time it has waited multiplied by its lane's weight, is taken next.
+// Request types without a lane have priority 0 and weight 1.
+type Lane struct {
+	gorm.Model
+	GuildID  string `gorm:"uniqueIndex:idx_lane"`
+	Name     string `gorm:"uniqueIndex:idx_lane"`
+	Priority int
+	Weight   int `gorm:"default:1"`
+}
+
+// Shift records a period in which a teaching assistant was on duty in a guild.
+type Shift struct {
+	gorm.Model
+	GuildID string `gorm:"index"`
+	UserID  string
+	// OnDuty is true until the shift ends.
+	OnDuty    bool
+	StartedAt time.Time
+	EndedAt   time.Time
+	// Reason describes how the shift ended, i.e., "offDuty" or "labHoursEnded".
+	Reason string
+}
+
+// AuditKind is the kind of an audit log entry.
+type AuditKind string
+
+const (
+	// AuditCommand records a slash command used in the guild.
+	AuditCommand AuditKind = "command"
+	// AuditInteraction records a button, select menu or modal used in the guild.
+	AuditInteraction AuditKind = "interaction"
+	// AuditChange records a change to a help request, student or course.
+	AuditChange AuditKind = "change"
+)
+
+// AuditEntry records a slash command, an interaction with a component or modal, or a change to a help request, student or course.
+type AuditEntry struct {
+	gorm.Model
+	GuildID string `gorm:"index"`
+	// UserID is the user who used the command or component, or the student affected by the change.
+	UserID string `gorm:"index"`
+	// ActorID is the user who made the change through a command or interaction, or "api" for the queue API.
+	ActorID string `gorm:"index"`
+	Kind    AuditKind
+	// Action is the name of the command, the custom ID prefix of the component or modal, or "create", "update" or "delete".
+	Action string
+	// Target is the table and primary key of the changed record.
+	Target string
+	// Details is the command's options, the component's custom ID arguments and selected values,
+	// or the names of the columns set by the change.
+	Details string
+	// Outcome is the bot's reply to the command or interaction.
+	Outcome string
+	// Error is the error that occurred while replying to the command or interaction, if any.
+	Error string
 }
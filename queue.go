@@ -0,0 +1,272 @@
+package helpbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// scheduleInterval is how often the lab hours are checked to open or close the queues.
+const scheduleInterval = time.Minute
+
+var weekdayChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "Monday", Value: int(time.Monday)},
+	{Name: "Tuesday", Value: int(time.Tuesday)},
+	{Name: "Wednesday", Value: int(time.Wednesday)},
+	{Name: "Thursday", Value: int(time.Thursday)},
+	{Name: "Friday", Value: int(time.Friday)},
+	{Name: "Saturday", Value: int(time.Saturday)},
+	{Name: "Sunday", Value: int(time.Sunday)},
+}
+
+// inLabHours returns true if t is within any of the lab hours.
+func inLabHours(hours []*models.LabHours, t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	for _, h := range hours {
+		if h.Weekday == t.Weekday() && h.Start <= minute && minute < h.End {
+			return true
+		}
+	}
+	return false
+}
+
+// nextLabHours returns the start of the first lab hours after t.
+// It returns false if there are no lab hours.
+func nextLabHours(hours []*models.LabHours, t time.Time) (next time.Time, ok bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for _, h := range hours {
+		days := (int(h.Weekday) - int(t.Weekday()) + 7) % 7
+		start := midnight.AddDate(0, 0, days).Add(time.Duration(h.Start) * time.Minute)
+		if !start.After(t) {
+			start = start.AddDate(0, 0, 7)
+		}
+		if !ok || start.Before(next) {
+			next, ok = start, true
+		}
+	}
+	return next, ok
+}
+
+// lastLabHoursEnd returns the end of the last lab hours that ended before or at t.
+// It returns false if there are no lab hours.
+func lastLabHoursEnd(hours []*models.LabHours, t time.Time) (last time.Time, ok bool) {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for _, h := range hours {
+		days := (int(t.Weekday()) - int(h.Weekday) + 7) % 7
+		end := midnight.AddDate(0, 0, -days).Add(time.Duration(h.End) * time.Minute)
+		if end.After(t) {
+			end = end.AddDate(0, 0, -7)
+		}
+		if !ok || end.After(last) {
+			last, ok = end, true
+		}
+	}
+	return last, ok
+}
+
+// formatMinutes formats minutes after midnight as HH:MM.
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// parseMinutes parses HH:MM as minutes after midnight.
+func parseMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// runSchedule opens and closes the queues according to the lab hours until the context is canceled.
+func (bot *HelpBot) runSchedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now().In(bot.location)
+		bot.updateQueueStates(now)
+		bot.endLongShifts(now)
+		bot.pruneAuditLog(now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateQueueStates opens the queues of courses whose lab hours have started, and closes the queues
+// of courses whose lab hours have ended. Courses without lab hours and frozen queues are left alone.
+// The queue state is only changed when lab hours start or end while the bot is running, so that
+// assistants can open or close the queue manually in between.
+func (bot *HelpBot) updateQueueStates(now time.Time) {
+	courses, err := bot.db.GetCourses()
+	if err != nil {
+		return
+	}
+	for _, course := range courses {
+		if course.GuildID == "" {
+			continue
+		}
+		hours, err := bot.db.GetLabHours(course.GuildID)
+		if err != nil || len(hours) == 0 {
+			continue
+		}
+		active := inLabHours(hours, now)
+		prev, ok := bot.labHoursActive[course.GuildID]
+		if ok && prev == active {
+			continue
+		}
+		bot.labHoursActive[course.GuildID] = active
+		if !active && (prev || !ok) {
+			// The lab hours ended, either while the bot was running or while it was offline
+			if end, ok := lastLabHoursEnd(hours, now); ok {
+				bot.endShifts(course.GuildID, end)
+			}
+		}
+		if !ok {
+			// The first check after the bot starts only records whether the lab hours are active,
+			// so that a queue opened or closed manually before a restart is left as it is
+			continue
+		}
+
+		state := models.QueueClosed
+		if active {
+			state = models.QueueOpen
+		}
+		if course.QueueState == models.QueueFrozen || course.QueueState == state {
+			continue
+		}
+		if err := bot.db.SetQueueState(course.GuildID, state); err != nil {
+			continue
+		}
+		bot.log.Infof("Lab hours: queue for %s is now %s", course.Name, state)
+		bot.queueChanged(course.GuildID)
+	}
+}
+
+// queueClosedMsg returns a message explaining why the queue does not accept new requests,
+// or an empty string if the queue is open.
+func (bot *HelpBot) queueClosedMsg(guildID string) string {
+	course, err := bot.db.GetCourse(&models.Course{GuildID: guildID})
+	if err != nil {
+		return "This server is not configured with a course."
+	}
+	switch course.QueueState {
+	case models.QueueFrozen:
+		return "The queue is frozen, and no new requests are accepted at the moment."
+	case models.QueueClosed:
+		hours, err := bot.db.GetLabHours(guildID)
+		if err != nil {
+			return "The queue is closed."
+		}
+		if next, ok := nextLabHours(hours, time.Now().In(bot.location)); ok {
+			return fmt.Sprintf("The queue is closed. The next lab hours start <t:%d:R>.", next.Unix())
+		}
+		return "The queue is closed."
+	}
+	return ""
+}
+
+// queueStateCommand returns a command that sets the queue state of the guild's course.
+func (bot *HelpBot) queueStateCommand(state models.QueueState) command {
+	return func(m *discordgo.InteractionCreate) {
+		if err := bot.actorDB(m).SetQueueState(m.GuildID, state); err != nil {
+			replyMsg(bot.client, m, fmt.Sprintf("Failed to update the queue: %v", err))
+			return
+		}
+		bot.queueChanged(m.GuildID)
+		switch state {
+		case models.QueueOpen:
+			replyMsg(bot.client, m, "The queue is now open.")
+		case models.QueueClosed:
+			replyMsg(bot.client, m, "The queue is now closed. Students in the queue will still receive help.")
+		case models.QueueFrozen:
+			replyMsg(bot.client, m, "The queue is now frozen. It will not be opened automatically until you open it.")
+		}
+	}
+}
+
+func (bot *HelpBot) labHoursCommand(m *discordgo.InteractionCreate) {
+	options := m.ApplicationCommandData().Options
+	if len(options) == 0 {
+		replyMsg(bot.client, m, "You must specify a subcommand.")
+		return
+	}
+	subcommand := options[0]
+	args := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, opt := range subcommand.Options {
+		args[opt.Name] = opt
+	}
+
+	switch subcommand.Name {
+	case "add":
+		start, err := parseMinutes(args["start"].StringValue())
+		if err != nil {
+			replyMsg(bot.client, m, err.Error())
+			return
+		}
+		end, err := parseMinutes(args["end"].StringValue())
+		if err != nil {
+			replyMsg(bot.client, m, err.Error())
+			return
+		}
+		if end <= start {
+			replyMsg(bot.client, m, "The lab hours must end after they start.")
+			return
+		}
+		hours := &models.LabHours{
+			GuildID: m.GuildID,
+			Weekday: time.Weekday(args["day"].IntValue()),
+			Start:   start,
+			End:     end,
+		}
+		if err := bot.actorDB(m).CreateLabHours(hours); err != nil {
+			replyMsg(bot.client, m, "Failed to add lab hours.")
+			return
+		}
+		replyMsg(bot.client, m, fmt.Sprintf("Added lab hours %d: %s %s-%s.", hours.ID, hours.Weekday, formatMinutes(start), formatMinutes(end)))
+
+	case "list":
+		hours, err := bot.db.GetLabHours(m.GuildID)
+		if err != nil {
+			replyMsg(bot.client, m, "Failed to get lab hours.")
+			return
+		}
+		if len(hours) == 0 {
+			replyMsg(bot.client, m, "There are no lab hours. The queue must be opened and closed manually.")
+			return
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "Lab hours (%s):\n\n", bot.location)
+		for _, h := range hours {
+			fmt.Fprintf(&sb, "%d. %s %s-%s\n", h.ID, h.Weekday, formatMinutes(h.Start), formatMinutes(h.End))
+		}
+		replyMsg(bot.client, m, sb.String())
+
+	case "remove":
+		if err := bot.actorDB(m).DeleteLabHours(m.GuildID, uint(args["id"].IntValue())); err != nil {
+			replyMsg(bot.client, m, fmt.Sprintf("Failed to remove lab hours: %v", err))
+			return
+		}
+		replyMsg(bot.client, m, "The lab hours were removed.")
+	}
+}
+
+func (bot *HelpBot) strategyCommand(m *discordgo.InteractionCreate) {
+	opt := getOption(m, "strategy")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must specify an assignment strategy.")
+		return
+	}
+	strategy := models.AssignmentStrategy(opt.StringValue())
+	if err := bot.actorDB(m).SetAssignmentStrategy(m.GuildID, strategy); err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to set the assignment strategy: %v", err))
+		return
+	}
+	replyMsg(bot.client, m, fmt.Sprintf("New requests are now assigned to waiting teaching assistants using the '%s' strategy.", strategy))
+}
@@ -0,0 +1,121 @@
+package helpbot
+
+import (
+	"fmt"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// sessionArchiveDuration is the number of minutes of inactivity after which Discord archives a session thread
+// that was not archived when the session was closed.
+const sessionArchiveDuration = 24 * 60
+
+// createSessionThread creates a private thread for the request's session that only the student(s) and the
+// assigned assistant can see. The thread is created in the course's session channel, or in the given channel if
+// the course has no session channel. It returns the thread, or nil if the thread could not be created.
+func (bot *HelpBot) createSessionThread(channelID string, req *models.HelpRequest) *discordgo.Channel {
+	if course, err := bot.db.GetCourse(&models.Course{GuildID: req.GuildID}); err == nil && course.SessionChannelID != "" {
+		channelID = course.SessionChannelID
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%d", req.Type, req.ID)
+	if req.AssignmentName != "" {
+		name = fmt.Sprintf("%s %s", name, req.AssignmentName)
+	}
+	thread, err := bot.client.ThreadStartComplex(channelID, &discordgo.ThreadStart{
+		Name:                truncate(name, 100),
+		AutoArchiveDuration: sessionArchiveDuration,
+		Type:                discordgo.ChannelTypeGuildPrivateThread,
+		Invitable:           false,
+	})
+	if err != nil {
+		bot.log.Errorln("Failed to create session thread:", err)
+		return nil
+	}
+
+	for _, userID := range append([]string{req.AssistantUserID, req.StudentUserID}, req.GroupMemberIDs...) {
+		if err := bot.client.ThreadMemberAdd(thread.ID, userID); err != nil {
+			bot.log.Errorf("Failed to add %s to session thread: %v", userID, err)
+		}
+	}
+	if err := bot.db.SetThreadID(req, thread.ID); err != nil {
+		bot.log.Errorln("Failed to save session thread:", err)
+	}
+
+	msg := fmt.Sprintf("Session for <@%s>%s with <@%s>. This thread is archived when the session is closed.",
+		req.StudentUserID, forGroup(req), req.AssistantUserID)
+	var embeds []*discordgo.MessageEmbed
+	if req.Description != "" {
+		embeds = append(embeds, descriptionEmbed(req))
+	}
+	if _, err := bot.client.ChannelMessageSendComplex(thread.ID, &discordgo.MessageSend{Content: msg, Embeds: embeds}); err != nil {
+		bot.log.Errorln("Failed to send message to session thread:", err)
+	}
+	bot.log.Infof("Created session thread: %s", thread.Name)
+	return thread
+}
+
+// openSession starts the session of a request that was assigned to an assistant. It moves the students into
+// the assistant's voice channel, creates the session thread, and notifies the students that assistant helps them.
+// The thread is created in the given channel if the course has no session channel. openSession is shared by
+// the slash commands and the queue API, and returns the thread, if any, and a message about the voice move.
+func (bot *HelpBot) openSession(channelID string, req *models.HelpRequest, assistant string) (*discordgo.Channel, string) {
+	voiceMsg := bot.moveToAssistantVoice(req)
+	thread := bot.createSessionThread(channelID, req)
+	bot.notifyStudents(req, fmt.Sprintf("You will now receive help from %s.%s", assistant, threadMsg(thread)))
+	return thread, voiceMsg
+}
+
+// sessionMsg returns a message telling the assistant which request they were assigned.
+func sessionMsg(req *models.HelpRequest, student string, thread *discordgo.Channel, voiceMsg string) string {
+	return fmt.Sprintf("Next '%s' request%s is by %s%s.%s%s", req.Type, forAssignment(req), student, forGroup(req), threadMsg(thread), voiceMsg)
+}
+
+// notifyAssistant sends the assistant a direct message about the request they were assigned,
+// when there is no interaction by the assistant to reply to.
+func (bot *HelpBot) notifyAssistant(req *models.HelpRequest, student string, thread *discordgo.Channel, voiceMsg string) {
+	msg := sessionMsg(req, student, thread, voiceMsg)
+	if req.Description != "" {
+		msg += fmt.Sprintf("\n> %s", truncate(req.Description, 500))
+	}
+	bot.dm.send(req.AssistantUserID, msg)
+}
+
+// archiveSessionThread archives and locks the thread of the request's session, if any.
+func (bot *HelpBot) archiveSessionThread(req *models.HelpRequest) {
+	if req == nil || req.ThreadID == "" {
+		return
+	}
+	archived, locked := true, true
+	if _, err := bot.client.ChannelEdit(req.ThreadID, &discordgo.ChannelEdit{Archived: &archived, Locked: &locked}); err != nil {
+		bot.log.Errorln("Failed to archive session thread:", err)
+	}
+}
+
+// threadMsg returns a message linking to the session thread, or an empty string if there is no thread.
+func threadMsg(thread *discordgo.Channel) string {
+	if thread == nil {
+		return ""
+	}
+	return fmt.Sprintf(" Session thread: <#%s>", thread.ID)
+}
+
+func (bot *HelpBot) sessionChannelCommand(m *discordgo.InteractionCreate) {
+	var channelID string
+	if opt := getOption(m, "channel"); opt != nil {
+		channelID = opt.ChannelValue(nil).ID
+	}
+	if err := bot.actorDB(m).SetSessionChannel(m.GuildID, channelID); err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to update course: %v", err))
+		return
+	}
+	if channelID == "" {
+		replyMsg(bot.client, m, "Session threads will be created in the channel where the session is started.")
+		return
+	}
+	replyMsg(bot.client, m, fmt.Sprintf("Session threads will be created in <#%s>.", channelID))
+}
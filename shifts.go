@@ -0,0 +1,81 @@
+package helpbot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxShiftLength is how long an assistant can be on duty before being signed off automatically,
+// e.g., after forgetting to use /offduty in a course without lab hours.
+const maxShiftLength = 12 * time.Hour
+
+func (bot *HelpBot) onDutyCommand(m *discordgo.InteractionCreate) {
+	if _, err := bot.actorDB(m).StartShift(m.GuildID, m.Member.User.ID); err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to go on duty: %s", err))
+		return
+	}
+	bot.queueChanged(m.GuildID)
+	replyMsg(bot.client, m, "You are now on duty. Use /next to get the first student, and /offduty when you are done.")
+}
+
+func (bot *HelpBot) offDutyCommand(m *discordgo.InteractionCreate) {
+	shift, err := bot.actorDB(m).EndShift(m.GuildID, m.Member.User.ID)
+	if err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to go off duty: %s", err))
+		return
+	}
+	bot.queueChanged(m.GuildID)
+
+	msg := fmt.Sprintf("You are now off duty after %s.", shift.EndedAt.Sub(shift.StartedAt).Round(time.Minute))
+	if session, err := bot.db.GetSession(m.Member.User.ID, m.GuildID); err == nil && session != nil {
+		msg += " Remember to close your current session with /done."
+	}
+	replyMsg(bot.client, m, msg)
+}
+
+// endShifts signs off the assistants in the guild that were already on duty when the lab hours ended.
+// Assistants that went on duty after the lab hours, e.g., to help outside of them, stay on duty.
+func (bot *HelpBot) endShifts(guildID string, labHoursEnd time.Time) {
+	shifts, err := bot.db.EndShiftsStartedBefore(guildID, labHoursEnd, "labHoursEnded")
+	if err != nil || len(shifts) == 0 {
+		return
+	}
+	for _, shift := range shifts {
+		bot.dm.send(shift.UserID, "The lab hours have ended, and you were signed off duty. Use /onduty to continue helping students.")
+	}
+	bot.queueChanged(guildID)
+}
+
+// endLongShifts signs off the assistants that have been on duty for longer than maxShiftLength.
+func (bot *HelpBot) endLongShifts(now time.Time) {
+	shifts, err := bot.db.EndShiftsStartedBefore("", now.Add(-maxShiftLength), "maxShiftLength")
+	if err != nil {
+		return
+	}
+	guilds := make(map[string]bool)
+	for _, shift := range shifts {
+		guilds[shift.GuildID] = true
+		bot.dm.send(shift.UserID, fmt.Sprintf("You have been on duty for more than %s, and were signed off duty. Use /onduty to continue helping students.", maxShiftLength))
+	}
+	for guildID := range guilds {
+		bot.queueChanged(guildID)
+	}
+}
+
+// onDutyMsg returns a message with the number of assistants on duty in the guild.
+func (bot *HelpBot) onDutyMsg(guildID string) string {
+	shifts, err := bot.db.GetOnDuty(guildID)
+	if err != nil {
+		return ""
+	}
+	switch len(shifts) {
+	case 0:
+		return " No teaching assistants are on duty."
+	case 1:
+		return " There is 1 teaching assistant on duty."
+	default:
+		return fmt.Sprintf(" There are %d teaching assistants on duty.", len(shifts))
+	}
+}
@@ -0,0 +1,80 @@
+package helpbot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// statsPeriods are the choices of the stats command's period option.
+var statsPeriods = []*discordgo.ApplicationCommandOptionChoice{
+	{Name: "today", Value: "today"},
+	{Name: "this week", Value: "week"},
+	{Name: "this course", Value: "course"},
+}
+
+// periodStart returns the start of the given period (today, week or course) in the given timezone.
+// The course period has no start, and the zero time is returned.
+func periodStart(period string, now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	switch period {
+	case "today":
+		return today
+	case "week":
+		// Weeks start on Monday
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	}
+	return time.Time{}
+}
+
+// formatDuration formats a duration as minutes and seconds.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func (bot *HelpBot) statsCommand(m *discordgo.InteractionCreate) {
+	period := "today"
+	if opt := getOption(m, "period"); opt != nil {
+		period = opt.StringValue()
+	}
+	since := periodStart(period, time.Now(), bot.location)
+
+	stats, err := bot.db.GetAssistantStats(m.GuildID, since)
+	if err != nil {
+		replyMsg(bot.client, m, "Failed to get statistics.")
+		return
+	}
+	if len(stats) == 0 {
+		replyMsg(bot.client, m, "No requests were handled in this period.")
+		return
+	}
+
+	var sb strings.Builder
+	if since.IsZero() {
+		sb.WriteString("Teaching assistant statistics for this course:\n\n")
+	} else {
+		fmt.Fprintf(&sb, "Teaching assistant statistics since <t:%d:f>:\n\n", since.Unix())
+	}
+	var handled int
+	for _, s := range stats {
+		handled += s.Handled
+		fmt.Fprintf(&sb, "<@%s>: %d handled", s.UserID, s.Handled)
+		if s.NoShows > 0 {
+			fmt.Fprintf(&sb, " (%d no-show)", s.NoShows)
+		}
+		if s.Handled > 0 {
+			fmt.Fprintf(&sb, ", average session %s, average wait %s", formatDuration(s.AverageSession), formatDuration(s.AverageWait))
+		}
+		if s.Clears > 0 {
+			fmt.Fprintf(&sb, ", %d clears (%d requests)", s.Clears, s.Cleared)
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "\n%d requests were handled by %d teaching assistants.", handled, len(stats))
+	replyMsg(bot.client, m, truncate(sb.String(), 2000))
+}
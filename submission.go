@@ -0,0 +1,221 @@
+package helpbot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+	qfpb "github.com/quickfeed/quickfeed/qf"
+)
+
+// quickFeedTimeout is the timeout for QuickFeed requests made while responding to an interaction.
+// Discord requires a response to an interaction within three seconds, so interactions that wait for
+// QuickFeed are deferred with deferReply.
+const quickFeedTimeout = 2 * time.Second
+
+// getQuickFeedID returns the QuickFeed user ID of the given student. Students that registered before
+// the QuickFeed user ID was stored are looked up by their GitHub login, and the ID is saved for later.
+func (bot *HelpBot) getQuickFeedID(ctx context.Context, course *models.Course, student *models.Student) (uint64, error) {
+	if student.QuickFeedID != 0 {
+		return student.QuickFeedID, nil
+	}
+	enrollment, err := bot.qf.GetEnrollment(ctx, course.CourseID, student.GithubLogin)
+	if err != nil {
+		return 0, err
+	}
+	if enrollment.GetUser() == nil {
+		return 0, fmt.Errorf("%s is not enrolled in %s", student.GithubLogin, course.Name)
+	}
+	student.QuickFeedID = enrollment.GetUser().GetID()
+	if err := bot.db.UpdateStudent(student); err != nil {
+		bot.log.Errorln("Failed to save QuickFeed user ID:", err)
+	}
+	return student.QuickFeedID, nil
+}
+
+// getLatestSubmission returns the latest submission for the request's assignment by the student who made the request,
+// along with the student's QuickFeed user ID. The submission is nil if the student has not made a submission for the assignment.
+func (bot *HelpBot) getLatestSubmission(ctx context.Context, req *models.HelpRequest) (*qfpb.Submission, uint64, error) {
+	course, err := bot.db.GetCourse(&models.Course{GuildID: req.GuildID})
+	if err != nil {
+		return nil, 0, err
+	}
+	student, err := bot.db.GetGuildStudent(req.GuildID, req.StudentUserID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if student == nil {
+		return nil, 0, fmt.Errorf("<@%s> is not registered", req.StudentUserID)
+	}
+	userID, err := bot.getQuickFeedID(ctx, course, student)
+	if err != nil {
+		return nil, 0, err
+	}
+	submission, err := bot.qf.GetLatestSubmission(ctx, course.CourseID, userID, req.GroupID, req.AssignmentID)
+	return submission, userID, err
+}
+
+// checkScoreLimit returns an error message if the student's latest submission for the request's assignment
+// is below the assignment's score limit. It returns an empty string if the request may be created.
+func (bot *HelpBot) checkScoreLimit(req *models.HelpRequest) string {
+	course, err := bot.db.GetCourse(&models.Course{GuildID: req.GuildID})
+	if err != nil || course.IgnoreScoreLimit {
+		return ""
+	}
+	scoreLimit := bot.getAssignment(req.GuildID, req.AssignmentID).GetScoreLimit()
+	if scoreLimit == 0 {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quickFeedTimeout)
+	defer cancel()
+	submission, _, err := bot.getLatestSubmission(ctx, req)
+	if err != nil {
+		// Do not keep students out of the queue because QuickFeed is unavailable.
+		bot.log.Errorln("Failed to get submission from QuickFeed:", err)
+		return ""
+	}
+	if submission == nil {
+		return fmt.Sprintf("You have no submission for %s on QuickFeed.", req.AssignmentName)
+	}
+	if submission.GetScore() < scoreLimit {
+		return fmt.Sprintf("Your current score for %s is %d%%, but a score of at least %d%% is required for approval.",
+			req.AssignmentName, submission.GetScore(), scoreLimit)
+	}
+	return ""
+}
+
+func (bot *HelpBot) scoreLimitCommand(m *discordgo.InteractionCreate) {
+	opt := getOption(m, "enforce")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must specify whether to enforce the score limit or not.")
+		return
+	}
+	ignore := !opt.BoolValue()
+	if err := bot.actorDB(m).SetIgnoreScoreLimit(m.GuildID, ignore); err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to update course: %v", err))
+		return
+	}
+	if ignore {
+		replyMsg(bot.client, m, "Students can now request approval regardless of their score.")
+	} else {
+		replyMsg(bot.client, m, "Students must now reach the assignment's score limit before requesting approval.")
+	}
+}
+
+// submissionStatuses maps the choices of the grade command to QuickFeed submission statuses.
+var submissionStatuses = map[string]qfpb.Submission_Status{
+	"approved": qfpb.Submission_APPROVED,
+	"rejected": qfpb.Submission_REJECTED,
+	"revision": qfpb.Submission_REVISION,
+}
+
+// getSubmissionStatus returns the approval status of the submission for the given user.
+func getSubmissionStatus(submission *qfpb.Submission, userID uint64) qfpb.Submission_Status {
+	for _, grade := range submission.GetGrades() {
+		if grade.GetUserID() == userID {
+			return grade.GetStatus()
+		}
+	}
+	return qfpb.Submission_NONE
+}
+
+// submissionEmbed returns an embed summarizing the submission for the request's assignment.
+func submissionEmbed(req *models.HelpRequest, submission *qfpb.Submission, userID uint64) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("Latest submission for %s", req.AssignmentName),
+		Color: 0x0000ff,
+	}
+	if submission == nil {
+		embed.Description = "No submission was found on QuickFeed."
+		return embed
+	}
+
+	build := "Not built"
+	if buildInfo := submission.GetBuildInfo(); buildInfo != nil {
+		build = fmt.Sprintf("Built <t:%d:R> in %s", buildInfo.GetBuildDate().AsTime().Unix(), time.Duration(buildInfo.GetExecTime())*time.Millisecond)
+	}
+	commit := "none"
+	if hash := submission.GetCommitHash(); hash != "" {
+		commit = fmt.Sprintf("`%.7s`", hash)
+	}
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{Name: "Score", Value: fmt.Sprintf("%d%%", submission.GetScore()), Inline: true},
+		{Name: "Status", Value: getSubmissionStatus(submission, userID).String(), Inline: true},
+		{Name: "Commit", Value: commit, Inline: true},
+		{Name: "Build", Value: build},
+	}
+	return embed
+}
+
+func (bot *HelpBot) gradeCommand(m *discordgo.InteractionCreate) {
+	opt := getOption(m, "status")
+	if opt == nil {
+		replyMsg(bot.client, m, "You must specify the status of the submission.")
+		return
+	}
+	status, ok := submissionStatuses[opt.StringValue()]
+	if !ok {
+		replyMsg(bot.client, m, "Invalid submission status.")
+		return
+	}
+
+	request, err := bot.db.GetSession(m.Member.User.ID, m.GuildID)
+	if err != nil {
+		replyMsg(bot.client, m, "An error occurred while fetching your current session.")
+		return
+	}
+	if request == nil || request.Type != "approve" || request.AssignmentID == 0 {
+		replyMsg(bot.client, m, "You do not have an approval session in progress.")
+		return
+	}
+
+	// Getting and updating the submission takes two requests to QuickFeed
+	if !deferReply(bot.client, m) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quickFeedTimeout)
+	defer cancel()
+	submission, userID, err := bot.getLatestSubmission(ctx, request)
+	if err != nil {
+		bot.log.Errorln("Failed to get submission from QuickFeed:", err)
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to get the submission from QuickFeed: %s", err))
+		return
+	}
+	if submission == nil {
+		replyMsg(bot.client, m, fmt.Sprintf("<@%s> has no submission for %s on QuickFeed.", request.StudentUserID, request.AssignmentName))
+		return
+	}
+
+	course, err := bot.db.GetCourse(&models.Course{GuildID: m.GuildID})
+	if err != nil {
+		replyMsg(bot.client, m, "An unknown error occurred.")
+		return
+	}
+	userIDs := []uint64{userID}
+	if request.GroupID != 0 {
+		// Set the status for every member of the group
+		for _, grade := range submission.GetGrades() {
+			if grade.GetUserID() != userID {
+				userIDs = append(userIDs, grade.GetUserID())
+			}
+		}
+	}
+	if err := bot.qf.UpdateSubmissionStatus(ctx, course.CourseID, submission, status, userIDs...); err != nil {
+		bot.log.Errorln("Failed to update submission on QuickFeed:", err)
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to update the submission on QuickFeed: %s", err))
+		return
+	}
+	if err := bot.actorDB(m).SetSubmissionStatus(request, submission.GetID(), status.String()); err != nil {
+		replyMsg(bot.client, m, "The submission was updated on QuickFeed, but the update could not be recorded.")
+		return
+	}
+
+	if !replyMsg(bot.client, m, fmt.Sprintf("The submission for %s by <@%s> was set to %s.", request.AssignmentName, request.StudentUserID, status)) {
+		return
+	}
+	bot.notifyStudents(request, fmt.Sprintf("Your submission for %s was set to %s by %s.", request.AssignmentName, status, getMentionAndNick(m.Member)))
+}
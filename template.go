@@ -1,6 +1,9 @@
 package helpbot
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
 
 func createModal(name, description string, withPrivacy bool) *discordgo.InteractionResponse {
 
@@ -35,3 +38,58 @@ If you wish to delete your data, please contact a teaching assistant.
 		},
 	}
 }
+
+// descriptionModal returns a modal asking the student to describe the problem they need help with.
+// The text inputs are filled with the given description and code location.
+func descriptionModal(customID, description, codeLocation string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    "Request help",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "description",
+							Label:       "What do you need help with?",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "A short description of the problem",
+							Value:       description,
+							Required:    true,
+							MaxLength:   500,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "location",
+							Label:       "Code location or error message",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "e.g., lab2/queue.go:42 or the compiler error",
+							Value:       codeLocation,
+							Required:    false,
+							MaxLength:   1000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// descriptionEmbed returns an embed with the problem description of the request.
+func descriptionEmbed(req *models.HelpRequest) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       "Description",
+		Color:       0x00ff00,
+		Description: req.Description,
+	}
+	if req.CodeLocation != "" {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Code location or error message", Value: "```\n" + req.CodeLocation + "\n```"},
+		}
+	}
+	return embed
+}
@@ -2,6 +2,7 @@ package helpbot
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
 	log "github.com/sirupsen/logrus"
@@ -9,15 +10,86 @@ import (
 
 // replyMsg replies to an interaction with a message.
 func replyMsg(s *discordgo.Session, m *discordgo.InteractionCreate, msg string) bool {
+	return replyEmbed(s, m, msg)
+}
+
+// replyEmbed replies to an interaction with a message and the given embeds.
+func replyEmbed(s *discordgo.Session, m *discordgo.InteractionCreate, msg string, embeds ...*discordgo.MessageEmbed) bool {
+	return reply(s, m, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content: msg,
+		Embeds:  embeds,
+	})
+}
+
+// replyComponents replies to an interaction with a message and the given message components.
+func replyComponents(s *discordgo.Session, m *discordgo.InteractionCreate, msg string, components ...discordgo.MessageComponent) bool {
+	return reply(s, m, discordgo.InteractionResponseChannelMessageWithSource, &discordgo.InteractionResponseData{
+		Content:    msg,
+		Components: components,
+	})
+}
+
+// updateMsg responds to a message component interaction by replacing the content and the components of the
+// message that the component belongs to. The message's components are removed if none are given.
+func updateMsg(s *discordgo.Session, m *discordgo.InteractionCreate, msg string, components ...discordgo.MessageComponent) bool {
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+	return reply(s, m, discordgo.InteractionResponseUpdateMessage, &discordgo.InteractionResponseData{
+		Content:    msg,
+		Components: components,
+	})
+}
+
+// deferredReplies holds the IDs of the interactions whose responses were deferred by deferReply.
+var deferredReplies sync.Map
+
+// deferReply acknowledges an interaction whose reply may take longer than the three seconds Discord allows,
+// e.g., because it waits for QuickFeed. The next reply to the interaction replaces the "thinking" message.
+// Deferring an interaction that is already deferred has no effect.
+func deferReply(s *discordgo.Session, m *discordgo.InteractionCreate) bool {
+	if _, deferred := deferredReplies.Load(m.ID); deferred {
+		return true
+	}
 	err := s.InteractionRespond(m.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Title:   m.ApplicationCommandData().Name,
-			Content: msg,
-			Flags:   discordgo.MessageFlagsEphemeral,
-		},
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
 	})
 	if err != nil {
+		discordErrors.WithLabelValues(opRespond).Inc()
+		log.Errorln("Failed to defer reply:", err)
+		return false
+	}
+	deferredReplies.Store(m.ID, true)
+	return true
+}
+
+// reply responds to an interaction with an ephemeral message.
+// If the response was deferred, the deferred response is edited instead.
+func reply(s *discordgo.Session, m *discordgo.InteractionCreate, typ discordgo.InteractionResponseType, data *discordgo.InteractionResponseData) bool {
+	if m.Type == discordgo.InteractionApplicationCommand {
+		data.Title = m.ApplicationCommandData().Name
+	}
+	data.Flags = discordgo.MessageFlagsEphemeral
+	var err error
+	if _, deferred := deferredReplies.LoadAndDelete(m.ID); deferred {
+		edit := &discordgo.WebhookEdit{Content: &data.Content, Files: data.Files}
+		if data.Embeds != nil {
+			edit.Embeds = &data.Embeds
+		}
+		if data.Components != nil {
+			edit.Components = &data.Components
+		}
+		_, err = s.InteractionResponseEdit(m.Interaction, edit)
+	} else {
+		err = s.InteractionRespond(m.Interaction, &discordgo.InteractionResponse{
+			Type: typ,
+			Data: data,
+		})
+	}
+	recordReply(m, data.Content, err)
+	if err != nil {
+		discordErrors.WithLabelValues(opRespond).Inc()
 		log.Errorln("Failed to get user:", err)
 		return false
 	}
@@ -25,21 +97,68 @@ func replyMsg(s *discordgo.Session, m *discordgo.InteractionCreate, msg string)
 }
 
 func replyModal(s *discordgo.Session, m *discordgo.InteractionCreate, resp *discordgo.InteractionResponse) bool {
-	if err := s.InteractionRespond(m.Interaction, resp); err != nil {
+	err := s.InteractionRespond(m.Interaction, resp)
+	recordReply(m, "Opened the form: "+resp.Data.Title, err)
+	if err != nil {
+		discordErrors.WithLabelValues(opRespond).Inc()
 		log.Errorln("Failed to get user:", err)
 		return false
 	}
 	return true
 }
 
+// getOption returns the command option with the given name, or nil if the option was not provided
+// or the interaction is not an application command.
+func getOption(m *discordgo.InteractionCreate, name string) *discordgo.ApplicationCommandInteractionDataOption {
+	if m.Type != discordgo.InteractionApplicationCommand {
+		return nil
+	}
+	for _, opt := range m.ApplicationCommandData().Options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+// modalValues returns the values of the text inputs in a submitted modal. key is the text input's custom ID.
+func modalValues(m *discordgo.InteractionCreate) map[string]string {
+	values := make(map[string]string)
+	for _, row := range m.ModalSubmitData().Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok {
+				values[input.CustomID] = input.Value
+			}
+		}
+	}
+	return values
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
+
 // sendMsg sends a direct message to a user.
 func sendMsg(s *discordgo.Session, u *discordgo.User, msg string) bool {
 	channel, err := s.UserChannelCreate(u.ID)
 	if err != nil {
+		discordErrors.WithLabelValues(opCreateChannel).Inc()
 		log.Errorln("Failed to create private channel:", err)
 		return false
 	}
-	s.ChannelMessageSend(channel.ID, msg)
+	if _, err := s.ChannelMessageSend(channel.ID, msg); err != nil {
+		discordErrors.WithLabelValues(opSendMessage).Inc()
+		log.Errorln("Failed to send direct message:", err)
+		return false
+	}
 	return true
 }
 
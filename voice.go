@@ -0,0 +1,65 @@
+package helpbot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Raytar/helpbot/models"
+	"github.com/bwmarrin/discordgo"
+)
+
+// moveToAssistantVoice moves the student(s) of the request into the assistant's current voice channel.
+// It returns a message for the assistant describing the result, or an empty string if the assistant is not in voice.
+func (bot *HelpBot) moveToAssistantVoice(req *models.HelpRequest) string {
+	assistantState, err := bot.client.State.VoiceState(req.GuildID, req.AssistantUserID)
+	if err != nil || assistantState.ChannelID == "" {
+		// The assistant is not in a voice channel
+		return ""
+	}
+
+	var moved, notInVoice []string
+	for _, userID := range append([]string{req.StudentUserID}, req.GroupMemberIDs...) {
+		state, err := bot.client.State.VoiceState(req.GuildID, userID)
+		if err != nil || state.ChannelID == "" {
+			notInVoice = append(notInVoice, fmt.Sprintf("<@%s>", userID))
+			continue
+		}
+		if state.ChannelID == assistantState.ChannelID {
+			continue
+		}
+		if err := bot.client.GuildMemberMove(req.GuildID, userID, &assistantState.ChannelID); err != nil {
+			bot.log.Errorf("Failed to move %s to voice channel: %v", userID, err)
+			notInVoice = append(notInVoice, fmt.Sprintf("<@%s>", userID))
+			continue
+		}
+		moved = append(moved, fmt.Sprintf("<@%s>", userID))
+	}
+
+	var sb strings.Builder
+	if len(moved) > 0 {
+		fmt.Fprintf(&sb, "\nMoved %s to <#%s>.", strings.Join(moved, ", "), assistantState.ChannelID)
+	}
+	if len(notInVoice) > 0 {
+		fmt.Fprintf(&sb, "\n%s could not be moved to your voice channel. Use /skip to mark the student as a no-show and take the next student.", strings.Join(notInVoice, ", "))
+	}
+	return sb.String()
+}
+
+func (bot *HelpBot) skipCommand(m *discordgo.InteractionCreate) {
+	request, err := bot.actorDB(m).CloseSession(m.Member.User.ID, m.GuildID, models.StatusNoShow)
+	if err != nil {
+		replyMsg(bot.client, m, fmt.Sprintf("Failed to close session: %s", err))
+		return
+	}
+	if request == nil {
+		replyMsg(bot.client, m, "You do not have a session in progress.")
+		return
+	}
+	observeSessionTime(request)
+	bot.archiveSessionThread(request)
+	msg := fmt.Sprintf("%s could not reach you, and your help request was closed. You may request help again.", getMentionAndNick(m.Member))
+	for _, userID := range append([]string{request.StudentUserID}, request.GroupMemberIDs...) {
+		bot.dm.send(userID, msg)
+	}
+	bot.nextRequestCommand(m)
+}